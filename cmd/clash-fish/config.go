@@ -1,27 +1,72 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 
 	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/internal/convert"
 	"github.com/clash-fish/clash-fish/pkg/logger"
+	"github.com/clash-fish/clash-fish/pkg/utils"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
+var (
+	configShowOrigin     bool
+	configValidateFormat string
+	configSetForce       bool
+	configReload         bool
+	configTemplate       string
+	configListTemplates  bool
+	configInitForce      bool
+	configSchemaOutput   string
+	configMigrateDryRun  bool
+	configImportFrom     string
+	configImportOutput   string
+	configImportForce    bool
+	configExportTo       string
+	configExportOutput   string
+)
+
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Manage configuration files",
-	Long:  `Manage clash-fish configuration files including init, edit, validate and show.`,
+	Long:  `Manage clash-fish configuration files including init, edit, validate, show, get and set.`,
 }
 
 var configInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize configuration",
-	Long:  `Create default configuration file in the config directory.`,
-	RunE:  runConfigInit,
+	Long: `Create the configuration file in the config directory from a built-in template
+(default: TUN with fake-ip DNS). Use --list-templates to see all templates.`,
+	RunE: runConfigInit,
+}
+
+var configDiffCmd = &cobra.Command{
+	Use:   "diff <source> [source]",
+	Short: "Compare two configurations",
+	Long: `Compare two configurations by structure: settings changed, proxies added,
+removed or modified (matched by name), groups whose members changed, and rules
+added, removed or moved.
+
+With one source, shows what would change if the active configuration were
+replaced by it. With two, compares the first against the second.
+
+Sources:
+  active              the active configuration (config.yaml)
+  profile:<name>      a stored profile
+  template:<name>     a built-in template
+  backup[:<n>]        a backup of the active configuration (0 = newest)
+  <path>              any configuration file`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runConfigDiff,
 }
 
 var configEditCmd = &cobra.Command{
@@ -46,24 +91,50 @@ var configShowCmd = &cobra.Command{
 }
 
 func runConfigInit(cmd *cobra.Command, args []string) error {
-	logger.Info().Str("dir", configDir).Msg("Initializing configuration...")
+	if configListTemplates {
+		fmt.Println("=== Templates ===")
+		for _, t := range config.Templates() {
+			fmt.Printf("  %-14s %s\n", t.Name, t.Description)
+		}
+		return nil
+	}
+
+	template, err := config.GetTemplate(configTemplate)
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Str("dir", configDir).Str("template", template.Name).Msg("Initializing configuration...")
 
 	// 创建配置管理器
 	mgr := config.NewManager(configDir)
 
 	// 检查配置是否已存在
-	if mgr.Exists() {
+	exists := mgr.Exists()
+	if exists && !configInitForce {
 		fmt.Printf("⚠ Configuration already exists at: %s\n", mgr.GetConfigPath())
-		fmt.Println("Use 'clash-fish config show' to view current configuration")
+		fmt.Println("Use 'clash-fish config show' to view current configuration, or --force to replace it")
 		return nil
 	}
 
+	// 模板写入前先校验
+	cfg := template.Build()
+	if err := config.Validate(cfg); err != nil {
+		return fmt.Errorf("template %s is invalid: %w", template.Name, err)
+	}
+
 	// 初始化配置
-	if err := mgr.Init(); err != nil {
+	if err := mgr.InitWith(cfg); err != nil {
 		return fmt.Errorf("failed to initialize configuration: %w", err)
 	}
+	if exists {
+		// 替换已有配置，旧内容轮转到 .bak
+		if err := mgr.Save(cfg); err != nil {
+			return fmt.Errorf("failed to initialize configuration: %w", err)
+		}
+	}
 
-	fmt.Printf("✓ Configuration initialized at: %s\n", configDir)
+	fmt.Printf("✓ Configuration initialized at: %s (template: %s)\n", configDir, template.Name)
 	fmt.Printf("  Config file: %s\n", mgr.GetConfigPath())
 	fmt.Println("\nNext steps:")
 	fmt.Println("  1. Edit config: clash-fish config edit")
@@ -108,7 +179,9 @@ func runConfigEdit(cmd *cobra.Command, args []string) error {
 }
 
 func runConfigValidate(cmd *cobra.Command, args []string) error {
-	logger.Info().Msg("Validating configuration...")
+	if configValidateFormat != "text" && configValidateFormat != "json" {
+		return fmt.Errorf("invalid --format %q (must be text or json)", configValidateFormat)
+	}
 
 	mgr := config.NewManager(configDir)
 
@@ -117,34 +190,509 @@ func runConfigValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("configuration not found, run 'clash-fish config init' first")
 	}
 
-	// 加载配置
-	cfg, err := mgr.Load()
+	data, err := os.ReadFile(mgr.GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	// 收集所有问题
+	issues, err := config.ValidateData(data)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	valid := !config.HasErrors(issues)
+
+	if configValidateFormat == "json" {
+		out, err := json.MarshalIndent(struct {
+			File   string         `json:"file"`
+			Valid  bool           `json:"valid"`
+			Issues []config.Issue `json:"issues"`
+		}{mgr.GetConfigPath(), valid, append([]config.Issue{}, issues...)}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode result: %w", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		printIssues(issues)
+	}
+
+	if !valid {
+		// 问题已经输出，不再重复打印错误和用法
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = configValidateFormat == "json"
+		return fmt.Errorf("configuration is invalid")
+	}
+
+	if configValidateFormat == "text" {
+		cfg, err := config.Parse(data)
+		if err != nil {
+			return err
+		}
+		fmt.Println("✓ Configuration is valid")
+		fmt.Printf("  Mode: %s\n", cfg.Mode)
+		fmt.Printf("  HTTP Port: %d\n", cfg.Port)
+		fmt.Printf("  SOCKS Port: %d\n", cfg.SocksPort)
+		fmt.Printf("  TUN Enabled: %v\n", cfg.TUN.Enable)
+		fmt.Printf("  DNS Enabled: %v\n", cfg.DNS.Enable)
+	}
+
+	logger.Debug().Int("issues", len(issues)).Msg("Configuration validated")
+
+	return nil
+}
+
+// printIssues 按文本格式打印校验问题
+func printIssues(issues []config.Issue) {
+	for _, issue := range issues {
+		mark := "✗"
+		if issue.Severity == config.SeverityWarning {
+			mark = "⚠"
+		}
+		fmt.Printf("%s %-7s %s\n", mark, issue.Severity, issue.String())
+		if issue.Hint != "" {
+			fmt.Printf("          hint: %s\n", issue.Hint)
+		}
+	}
+	if len(issues) > 0 {
+		fmt.Println()
+	}
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <path>",
+	Short: "Print a configuration value",
+	Long: `Print the value at a dotted path, e.g. tun.stack, dns.nameserver[0] or proxies[-1].
+Scalars are printed as-is, lists and mappings as YAML.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <path> <value>",
+	Short: "Set a configuration value",
+	Long: `Set the value at a dotted path. Use [+] to append to a list.
+
+The value is checked against the field type (strings are stored as-is, other
+fields accept YAML such as true, 7890 or [1.1.1.1, 8.8.8.8]) and the whole
+configuration is validated before it is saved. Comments and unknown keys are kept.
+
+Examples:
+  clash-fish config set tun.stack gvisor
+  clash-fish config set dns.nameserver[+] 1.1.1.1
+  clash-fish config set sniffer.enable true --force`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <path>",
+	Short: "Remove a configuration value",
+	Long:  `Remove the key or list item at a dotted path, e.g. dns.fallback or rules[3].`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	mgr := config.NewManager(configDir)
+	if !mgr.Exists() {
+		return fmt.Errorf("configuration not found, run 'clash-fish config init' first")
+	}
+
+	data, err := os.ReadFile(mgr.GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	node, err := config.GetPath(data, args[0])
+	if err != nil {
+		return err
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		fmt.Println(node.Value)
+		return nil
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	path, value := args[0], args[1]
+	done := fmt.Sprintf("Set %s = %s", path, value)
+
+	// 结构之外的字段（--force）只能直接修改 YAML 节点
+	if configSetForce && !config.IsKnownPath(path) {
+		return saveConfigChange(cmd, func(mgr *config.Manager) ([]config.Issue, error) {
+			return mgr.Edit(func(data []byte) ([]byte, error) {
+				return config.SetPath(data, path, value, true)
+			})
+		}, done)
+	}
+	return saveConfigChange(cmd, func(mgr *config.Manager) ([]config.Issue, error) {
+		return mgr.Update(func(cfg *config.Config) error {
+			return config.SetConfigPath(cfg, path, value)
+		})
+	}, done)
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	done := fmt.Sprintf("Removed %s", path)
+
+	if !config.IsKnownPath(path) {
+		return saveConfigChange(cmd, func(mgr *config.Manager) ([]config.Issue, error) {
+			return mgr.Edit(func(data []byte) ([]byte, error) {
+				return config.UnsetPath(data, path)
+			})
+		}, done)
+	}
+	return saveConfigChange(cmd, func(mgr *config.Manager) ([]config.Issue, error) {
+		return mgr.Update(func(cfg *config.Config) error {
+			return config.UnsetConfigPath(cfg, path)
+		})
+	}, done)
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the configuration",
+	Long: `Print a JSON Schema generated from the configuration structure, including the
+allowed values checked by 'config validate'. To get completion and inline errors
+in VS Code's YAML extension, save it and reference it from config.yaml:
+
+  clash-fish config schema -o ~/.config/clash-fish/schema.json
+  # yaml-language-server: $schema=./schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigSchema,
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the configuration to the current format",
+	Long: `Upgrade an older configuration step by step to the current format version
+(` + config.VersionKey + `), e.g. renaming deprecated keys. The previous content is kept
+as config.yaml.bak. Older configurations are also migrated in memory whenever they
+are loaded, but only this command writes the result; use --dry-run to review the
+changes first.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrate,
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	mgr := config.NewManager(configDir)
+	if !mgr.Exists() {
+		return fmt.Errorf("configuration not found, run 'clash-fish config init' first")
+	}
+
+	result, err := mgr.Migrate(configMigrateDryRun)
+	if err != nil {
+		return err
+	}
+
+	if !result.Changed() {
+		if result.To != result.From {
+			fmt.Printf("✓ Nothing to migrate, version updated from %d to %d\n", result.From, result.To)
+			fmt.Printf("  Previous version saved as %s.bak\n", mgr.GetConfigPath())
+			return nil
+		}
+		fmt.Println("✓ Configuration is up to date, nothing to migrate")
+		return nil
+	}
+
+	for _, change := range result.Changes {
+		fmt.Printf("  • %s\n", change)
+	}
+	if configMigrateDryRun {
+		fmt.Printf("Would migrate configuration from version %d to %d (dry run, nothing written)\n", result.From, result.To)
+		return nil
+	}
+	fmt.Printf("✓ Configuration migrated from version %d to %d\n", result.From, result.To)
+	fmt.Printf("  Previous version saved as %s.bak\n", mgr.GetConfigPath())
+	return nil
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a configuration from another client",
+	Long: `Convert a Surge (.conf), sing-box (JSON) or Quantumult X configuration into a
+clash-fish configuration. Proxies, proxy groups and rules are converted; other
+settings use the defaults. Everything that could not be converted is listed in
+the report.
+
+The result replaces config.yaml (requires --force when it exists; the previous
+version is kept as config.yaml.bak) or is written to --output. Use "-" as the
+file to read from stdin, or --output - to print the result.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigImport,
+}
+
+func runConfigImport(cmd *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = io.ReadAll(io.LimitReader(os.Stdin, maxStdinSize))
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+
+	cfg, report, err := convert.Import(convert.Format(configImportFrom), data)
+	if err != nil {
+		return fmt.Errorf("failed to import %s config: %w", configImportFrom, err)
+	}
+
+	// 输出到标准输出时报告写到标准错误
+	out := os.Stdout
+	if configImportOutput == "-" {
+		out = os.Stderr
+	}
+	printConvertReport(out, report)
+
+	if err := config.Validate(cfg); err != nil {
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			printIssues(verr.Issues)
+		}
+		return fmt.Errorf("imported configuration is invalid, nothing written")
+	}
+
+	if configImportOutput != "" {
+		content, err := yaml.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		if configImportOutput == "-" {
+			fmt.Print(string(content))
+			return nil
+		}
+		if err := os.WriteFile(configImportOutput, content, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", configImportOutput, err)
+		}
+		fmt.Printf("✓ Configuration written to %s\n", configImportOutput)
+		return nil
+	}
+
+	mgr := config.NewManager(configDir)
+	exists := mgr.Exists()
+	if exists && !configImportForce {
+		fmt.Printf("⚠ Configuration already exists at: %s\n", mgr.GetConfigPath())
+		fmt.Println("Use --force to replace it, or --output to write the result elsewhere")
+		return nil
+	}
+	if err := mgr.InitWith(cfg); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	if exists {
+		// 替换已有配置，旧内容轮转到 .bak
+		if err := mgr.Save(cfg); err != nil {
+			return fmt.Errorf("failed to write configuration: %w", err)
+		}
+	}
+
+	fmt.Printf("✓ Configuration imported to: %s\n", mgr.GetConfigPath())
+	return nil
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the effective configuration for another client",
+	Long: `Convert the effective configuration (config.yaml with the local mixin, setting
+overrides and secrets applied) into a sing-box configuration: proxies, proxy
+groups (select/url-test), rules, DNS and TUN settings. Everything that could not
+be converted is listed in the report.
+
+Secret references are resolved, so the output contains credentials; files
+written with --output are only readable by the current user.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigExport,
+}
+
+func runConfigExport(cmd *cobra.Command, args []string) error {
+	mgr := config.NewManager(configDir)
+	if !mgr.Exists() {
+		return fmt.Errorf("configuration not found, run 'clash-fish config init' first")
+	}
+
+	data, err := mgr.BuildEffective()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	content, report, err := convert.Export(convert.Format(configExportTo), cfg)
+	if err != nil {
+		return fmt.Errorf("failed to export %s config: %w", configExportTo, err)
+	}
+
+	if configExportOutput == "" || configExportOutput == "-" {
+		printConvertReport(os.Stderr, report)
+		fmt.Print(string(content))
+		return nil
+	}
+
+	printConvertReport(os.Stdout, report)
+	if err := utils.WriteFileAtomic(configExportOutput, content, utils.PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configExportOutput, err)
+	}
+	fmt.Printf("✓ %s configuration written to %s\n", configExportTo, configExportOutput)
+	return nil
+}
+
+// printConvertReport 打印格式转换报告
+func printConvertReport(w io.Writer, report *convert.Report) {
+	fmt.Fprintf(w, "Converted %d proxies, %d groups, %d rules\n", report.Proxies, report.Groups, report.Rules)
+	if len(report.Skipped) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nNot converted (%d):\n", len(report.Skipped))
+	for _, s := range report.Skipped {
+		if s.Item == "" {
+			fmt.Fprintf(w, "  • [%s] %s\n", s.Section, s.Reason)
+			continue
+		}
+		fmt.Fprintf(w, "  • [%s] %s: %s\n", s.Section, s.Item, s.Reason)
+	}
+	fmt.Fprintln(w)
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	out, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	out = append(out, '\n')
+
+	if configSchemaOutput == "" || configSchemaOutput == "-" {
+		fmt.Print(string(out))
+		return nil
+	}
+	if err := os.WriteFile(configSchemaOutput, out, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	fmt.Printf("✓ Schema written to %s\n", configSchemaOutput)
+	return nil
+}
+
+func runConfigDiff(cmd *cobra.Command, args []string) error {
+	from, to := "active", args[0]
+	if len(args) == 2 {
+		from, to = args[0], args[1]
+	}
 
-	// 验证配置
-	if err := mgr.Validate(cfg); err != nil {
-		fmt.Println("✗ Configuration is invalid:")
-		fmt.Printf("  Error: %v\n", err)
+	fromData, err := readConfigSource(from)
+	if err != nil {
+		return err
+	}
+	toData, err := readConfigSource(to)
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("✓ Configuration is valid")
-	fmt.Printf("  Mode: %s\n", cfg.Mode)
-	fmt.Printf("  HTTP Port: %d\n", cfg.Port)
-	fmt.Printf("  SOCKS Port: %d\n", cfg.SocksPort)
-	fmt.Printf("  TUN Enabled: %v\n", cfg.TUN.Enable)
-	fmt.Printf("  DNS Enabled: %v\n", cfg.DNS.Enable)
+	diff, err := config.CompareData(fromData, toData)
+	if err != nil {
+		return err
+	}
 
-	logger.Info().Msg("Configuration validated successfully")
+	fmt.Printf("=== %s → %s ===\n", from, to)
+	printConfigDiff(diff)
+	return nil
+}
 
+// readConfigSource 读取 config diff 的配置来源：active、profile:<name>、template:<name>、backup[:<n>] 或文件路径
+func readConfigSource(source string) ([]byte, error) {
+	mgr := config.NewManager(configDir)
+	kind, name, _ := strings.Cut(source, ":")
+
+	switch kind {
+	case "active":
+		data, err := os.ReadFile(mgr.GetConfigPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read configuration: %w", err)
+		}
+		return data, nil
+	case "profile":
+		return newProfileStore().ReadConfig(name)
+	case "template":
+		template, err := config.GetTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := yaml.Marshal(template.Build())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal template: %w", err)
+		}
+		return data, nil
+	case "backup":
+		path := mgr.GetConfigPath() + ".bak"
+		if name != "" && name != "0" {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 0 || n >= config.BackupCount {
+				return nil, fmt.Errorf("invalid backup %q (must be 0-%d)", name, config.BackupCount-1)
+			}
+			path = fmt.Sprintf("%s.%d", path, n)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	return data, nil
+}
+
+// saveConfigChange 校验并保存对配置文件的修改，可选重载运行中的服务
+func saveConfigChange(cmd *cobra.Command, save func(mgr *config.Manager) ([]config.Issue, error), done string) error {
+	mgr := config.NewManager(configDir)
+	if !mgr.Exists() {
+		return fmt.Errorf("configuration not found, run 'clash-fish config init' first")
+	}
+
+	issues, err := save(mgr)
+	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			printIssues(validationErr.Issues)
+			cmd.SilenceUsage = true
+			return fmt.Errorf("change not saved: configuration would be invalid")
+		}
+		return err
+	}
+
+	printIssues(issues)
+	fmt.Printf("✓ %s\n", done)
+
+	if configReload {
+		return reloadIfRunning()
+	}
 	return nil
 }
 
 func runConfigShow(cmd *cobra.Command, args []string) error {
 	mgr := config.NewManager(configDir)
 
+	if configShowOrigin {
+		printSettingOrigins()
+		if !mgr.Exists() {
+			return nil
+		}
+		fmt.Println()
+	}
+
 	// 检查配置文件是否存在
 	if !mgr.Exists() {
 		return fmt.Errorf("configuration not found, run 'clash-fish config init' first")
@@ -164,7 +712,11 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("=== Current Configuration ===")
 	fmt.Printf("Config File: %s\n", mgr.GetConfigPath())
-	fmt.Printf("Config Dir:  %s\n\n", configDir)
+	fmt.Printf("Config Dir:  %s\n", configDir)
+	if _, err := os.Stat(mgr.GetMixinPath()); err == nil {
+		fmt.Printf("Mixin File:  %s (applied at start)\n", mgr.GetMixinPath())
+	}
+	fmt.Println()
 
 	// 显示主要配置
 	fmt.Printf("Mode:        %s\n", cfg.Mode)
@@ -197,12 +749,53 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printSettingOrigins 打印分层设置的取值及来源
+func printSettingOrigins() {
+	fmt.Println("=== Settings (default → file → env → flag) ===")
+	for _, key := range settings.Keys() {
+		origin := string(settings.Origin(key))
+		switch settings.Origin(key) {
+		case config.OriginEnv:
+			origin += " (" + config.EnvName(key) + ")"
+		case config.OriginFlag:
+			origin += " (--" + config.FlagName(key) + ")"
+		}
+		fmt.Printf("%-20s %-40v %s\n", key, settings.Value(key), origin)
+	}
+}
+
 func init() {
+	configValidateCmd.Flags().StringVar(&configValidateFormat, "format", "text", "output format: text or json")
+	configShowCmd.Flags().BoolVar(&configShowOrigin, "origin", false, "show where each setting value came from")
+	configSetCmd.Flags().BoolVar(&configSetForce, "force", false, "allow keys that are not part of the clash-fish schema")
+	configSetCmd.Flags().BoolVar(&configReload, "reload", false, "reload the running service after saving")
+	configUnsetCmd.Flags().BoolVar(&configReload, "reload", false, "reload the running service after saving")
+	configInitCmd.Flags().StringVarP(&configTemplate, "template", "t", config.DefaultTemplate, "template to initialize from")
+	configInitCmd.Flags().BoolVar(&configListTemplates, "list-templates", false, "list the built-in templates")
+	configSchemaCmd.Flags().StringVarP(&configSchemaOutput, "output", "o", "", "write the schema to a file instead of stdout")
+	configImportCmd.Flags().StringVar(&configImportFrom, "from", "", "source format: surge, singbox or qx")
+	configImportCmd.Flags().StringVarP(&configImportOutput, "output", "o", "", "write the result to a file instead of config.yaml (- for stdout)")
+	configImportCmd.Flags().BoolVar(&configImportForce, "force", false, "replace the existing configuration")
+	configImportCmd.MarkFlagRequired("from")
+	configExportCmd.Flags().StringVar(&configExportTo, "to", "", "target format: singbox")
+	configExportCmd.Flags().StringVarP(&configExportOutput, "output", "o", "", "write to a file instead of stdout")
+	configExportCmd.MarkFlagRequired("to")
+	configMigrateCmd.Flags().BoolVar(&configMigrateDryRun, "dry-run", false, "show the changes without writing them")
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "replace an existing configuration (the old one is kept as a backup)")
+
 	// 添加子命令
 	configCmd.AddCommand(configInitCmd)
 	configCmd.AddCommand(configEditCmd)
 	configCmd.AddCommand(configValidateCmd)
 	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configDiffCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configImportCmd)
+	configCmd.AddCommand(configExportCmd)
 
 	// 添加到根命令
 	rootCmd.AddCommand(configCmd)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/clash-fish/clash-fish/internal/config"
 	"github.com/clash-fish/clash-fish/pkg/constants"
 	"github.com/clash-fish/clash-fish/pkg/logger"
 	"github.com/spf13/cobra"
@@ -13,6 +14,9 @@ var (
 	// 全局标志
 	configDir string
 	debug     bool
+
+	// settings 分层加载后的运行设置
+	settings *config.Settings
 )
 
 var rootCmd = &cobra.Command{
@@ -21,11 +25,24 @@ var rootCmd = &cobra.Command{
 	Long:    `Clash-Fish is a CLI tool for macOS that provides transparent proxy with VPN coexistence.`,
 	Version: constants.Version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 加载分层设置：默认值 → config.yaml → CLASH_FISH_* 环境变量 → 命令行标志
+		s, err := config.LoadAndBind(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		settings = s
+		configDir = s.ConfigDir
+		for _, warning := range s.Warnings() {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
 		// 初始化日志系统
-		logDir := constants.GetDefaultLogDir()
-		if err := logger.Init(logDir, debug); err != nil {
+		if err := logger.Init(s.LogDir, debug); err != nil {
 			return fmt.Errorf("failed to initialize logger: %w", err)
 		}
+		if !debug {
+			logger.SetLevel(s.LogLevel)
+		}
 		return nil
 	},
 }
@@ -33,6 +50,8 @@ var rootCmd = &cobra.Command{
 func init() {
 	// 全局标志
 	rootCmd.PersistentFlags().StringVar(&configDir, "config-dir", constants.GetDefaultConfigDir(), "config directory")
+	rootCmd.PersistentFlags().String("log-dir", "", "log directory (default <config-dir>/logs)")
+	rootCmd.PersistentFlags().String("log-level", "", "log level: debug/info/warning/error/silent")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug mode")
 }
 
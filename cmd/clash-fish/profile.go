@@ -2,11 +2,42 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/internal/proxy"
+	"github.com/clash-fish/clash-fish/internal/subscription"
 	"github.com/clash-fish/clash-fish/pkg/logger"
+	"github.com/clash-fish/clash-fish/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
+// maxStdinSize 从标准输入读取的配置大小上限
+const maxStdinSize = 16 << 20
+
+var (
+	profileInterval        time.Duration
+	profileSetInterval     time.Duration
+	profileHistoryLimit    int
+	profileSources         []string
+	profileSourceIntervals []string
+	profileFilter          subscription.Filter
+	profileRename          []string
+	profileOutput          string
+	profileRedact          bool
+	profileImportName      string
+	profileImportMixin     bool
+	profileFetch           subscription.FetchOptions
+	profileHeaders         []string
+	profileShowSecrets     bool
+)
+
 var profileCmd = &cobra.Command{
 	Use:   "profile",
 	Short: "Manage configuration profiles",
@@ -21,21 +52,44 @@ var profileListCmd = &cobra.Command{
 }
 
 var profileAddCmd = &cobra.Command{
-	Use:   "add <name> <url>",
+	Use:   "add <name> [url|path|-]",
 	Short: "Add a new profile",
-	Long:  `Add a new configuration profile from subscription URL.`,
-	Args:  cobra.ExactArgs(2),
-	RunE:  runProfileAdd,
+	Long: `Add a new configuration profile from subscription URL.
+
+The location may also be a local file path, a file:// URL, or - to read from stdin.
+Local files are re-read on 'profile update'; stdin content cannot be re-read.
+
+Use --source instead of <url> to merge several sources into one profile:
+  clash-fish profile add both --source a=https://a.example/sub --source b=profile:other
+A source is a subscription URL, file:<path> or profile:<name>.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runProfileAdd,
 }
 
 var profileUpdateCmd = &cobra.Command{
 	Use:   "update [name]",
 	Short: "Update profile(s)",
-	Long:  `Update configuration profile from subscription URL. If no name specified, update all.`,
+	Long:  `Force re-download of profile(s) from subscription URL. If no name specified, update all.`,
 	Args:  cobra.MaximumNArgs(1),
 	RunE:  runProfileUpdate,
 }
 
+var profileSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Change profile settings",
+	Long:  `Change settings of an existing profile, such as the auto update interval.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileSet,
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show profile details",
+	Long:  `Display profile settings, filters and the nodes kept after filtering.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileShow,
+}
+
 var profileSwitchCmd = &cobra.Command{
 	Use:   "switch <name>",
 	Short: "Switch to a profile",
@@ -44,6 +98,30 @@ var profileSwitchCmd = &cobra.Command{
 	RunE:  runProfileSwitch,
 }
 
+var profileHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "List previous versions of a profile",
+	Long:  `List the versions kept whenever an update changed the profile content.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileHistory,
+}
+
+var profileDiffCmd = &cobra.Command{
+	Use:   "diff <name> [rev]",
+	Short: "Show changes since a previous version",
+	Long:  `Compare a previous version (default: the latest one) with the current profile: settings, nodes, groups and rules.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runProfileDiff,
+}
+
+var profileRollbackCmd = &cobra.Command{
+	Use:   "rollback <name> <rev>",
+	Short: "Restore a previous version",
+	Long:  `Restore a previous version of the profile. The current content is kept in history.`,
+	Args:  cobra.ExactArgs(2),
+	RunE:  runProfileRollback,
+}
+
 var profileDeleteCmd = &cobra.Command{
 	Use:   "delete <name>",
 	Short: "Delete a profile",
@@ -52,59 +130,349 @@ var profileDeleteCmd = &cobra.Command{
 	RunE:  runProfileDelete,
 }
 
+var profileExportCmd = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Export a profile bundle",
+	Long:  `Pack the profile content, metadata, mixin overlay and referenced local rule files into a tar.gz bundle.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileExport,
+}
+
+var profileImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Import a profile bundle",
+	Long:  `Import a profile from a bundle created by 'profile export'.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileImport,
+}
+
 func runProfileList(cmd *cobra.Command, args []string) error {
-	// TODO: 实现 profile 列表逻辑
-	// 1. 读取 profiles 目录
-	// 2. 列出所有 profile
-	// 3. 标记当前激活的 profile
+	store := newProfileStore()
+
+	profiles, err := store.List()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
 
 	fmt.Println("=== Configuration Profiles ===")
-	fmt.Println("No profiles found. Use 'clash-fish profile add' to add one.")
+	if len(profiles) == 0 {
+		fmt.Println("No profiles found. Use 'clash-fish profile add' to add one.")
+		return nil
+	}
+
+	active, err := store.GetActive()
+	if err != nil {
+		return fmt.Errorf("failed to read active profile: %w", err)
+	}
+
+	for _, p := range profiles {
+		// 订阅链接中通常带有令牌，默认脱敏显示
+		if !profileShowSecrets {
+			subscription.RedactProfile(p)
+		}
+
+		marker := " "
+		if p.Name == active {
+			marker = "*"
+		}
+
+		nodes := "-"
+		if data, err := store.ReadConfig(p.Name); err == nil {
+			if cfg, err := config.Parse(data); err == nil {
+				nodes = fmt.Sprintf("%d", len(cfg.Proxies))
+			}
+		}
+
+		fmt.Printf("%s %s\n", marker, p.Name)
+		if p.IsAggregate() {
+			fmt.Println("    Sources:")
+			for _, src := range p.Sources {
+				fmt.Printf("      - %s (%s: %s)\n", src.Name, src.Kind(), src.Location())
+				if src.Interval > 0 {
+					fmt.Printf("        Interval: %s (next: %s)\n", time.Duration(src.Interval)*time.Second, src.NextUpdate().Format("2006-01-02 15:04:05"))
+				}
+				if src.UserInfo != nil {
+					fmt.Printf("        Traffic: %s, Expires: %s\n", src.UserInfo.FormatTraffic(), src.UserInfo.FormatExpire(time.Now()))
+				}
+			}
+		} else {
+			fmt.Printf("    %-9s %s\n", locationLabel(p)+":", p.Location())
+		}
+		fmt.Printf("    Updated:  %s\n", p.UpdatedAt.Format("2006-01-02 15:04:05"))
+		if p.Interval > 0 {
+			fmt.Printf("    Interval: %s (next: %s)\n", p.GetInterval(), p.NextUpdate().Format("2006-01-02 15:04:05"))
+		} else if !p.IsAggregate() {
+			fmt.Println("    Interval: manual")
+		}
+		fmt.Printf("    Nodes:    %s\n", nodes)
+		if p.UserInfo != nil {
+			fmt.Printf("    Traffic:  %s\n", p.UserInfo.FormatTraffic())
+			fmt.Printf("    Expires:  %s\n", p.UserInfo.FormatExpire(time.Now()))
+		}
+	}
 
 	return nil
 }
 
 func runProfileAdd(cmd *cobra.Command, args []string) error {
 	name := args[0]
-	url := args[1]
+
+	profile := &subscription.Profile{Name: name}
+	switch {
+	case len(args) > 1 && len(profileSources) > 0:
+		return fmt.Errorf("specify either <url> or --source, not both")
+	case len(args) > 1 && args[1] == "-":
+		// 标准输入内容无法重新读取，不开启自动更新
+	case len(args) > 1:
+		path, local, err := parseLocalPath(args[1])
+		if err != nil {
+			return err
+		}
+		if local {
+			profile.File = path
+		} else {
+			profile.URL = args[1]
+		}
+		profile.Interval = int(profileInterval.Seconds())
+	case len(profileSources) > 0:
+		sources, err := parseSources(profileSources, profileSourceIntervals)
+		if err != nil {
+			return err
+		}
+		profile.Sources = sources
+	default:
+		return fmt.Errorf("missing subscription <url> or --source")
+	}
+
+	filter, err := filterFromFlags(cmd, nil)
+	if err != nil {
+		return err
+	}
+	profile.Filter = filter
+
+	fetch, err := fetchFromFlags(cmd, nil)
+	if err != nil {
+		return err
+	}
+	profile.Fetch = fetch
 
 	logger.Info().
 		Str("name", name).
-		Str("url", url).
+		Str("location", profile.Location()).
+		Int("sources", len(profile.Sources)).
 		Msg("Adding profile...")
 
-	// TODO: 实现 profile 添加逻辑
-	// 1. 下载订阅内容
-	// 2. 解析配置
-	// 3. 保存到 profiles 目录
-	// 4. 更新 profile 元数据
+	importer := newImporter(newProfileStore())
+	if profile.Kind() == "stdin" {
+		data, err := io.ReadAll(io.LimitReader(cmd.InOrStdin(), maxStdinSize+1))
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		if len(data) > maxStdinSize {
+			return fmt.Errorf("stdin content is too large (limit %d bytes)", maxStdinSize)
+		}
+		if err := importer.AddContent(profile, data); err != nil {
+			return fmt.Errorf("failed to add profile: %w", err)
+		}
+	} else if err := importer.Add(cmd.Context(), profile); err != nil {
+		return fmt.Errorf("failed to add profile: %w", err)
+	}
 
 	fmt.Printf("✓ Profile '%s' added successfully\n", name)
+	fmt.Printf("  Hash: %s\n", profile.Hash[:12])
 	logger.Info().Str("name", name).Msg("Profile added")
 
 	return nil
 }
 
 func runProfileUpdate(cmd *cobra.Command, args []string) error {
-	var name string
+	store := newProfileStore()
+	importer := newImporter(store)
+
+	var names []string
 	if len(args) > 0 {
-		name = args[0]
-		logger.Info().Str("name", name).Msg("Updating profile...")
+		names = append(names, args[0])
+		logger.Info().Str("name", args[0]).Msg("Updating profile...")
 	} else {
 		logger.Info().Msg("Updating all profiles...")
+
+		profiles, err := store.List()
+		if err != nil {
+			return fmt.Errorf("failed to list profiles: %w", err)
+		}
+		for _, p := range profiles {
+			// 标准输入导入的 profile 无法重新读取
+			if p.Kind() == "stdin" {
+				fmt.Printf("- Profile '%s' skipped (imported from stdin)\n", p.Name)
+				continue
+			}
+			names = append(names, p.Name)
+		}
 	}
 
-	// TODO: 实现 profile 更新逻辑
-	// 1. 读取 profile 的订阅 URL
-	// 2. 重新下载订阅
-	// 3. 更新配置文件
+	active, err := store.GetActive()
+	if err != nil {
+		return fmt.Errorf("failed to read active profile: %w", err)
+	}
 
-	if name != "" {
+	failed, activeUpdated := 0, false
+	for _, name := range names {
+		if _, err := importer.Update(cmd.Context(), name); err != nil {
+			fmt.Printf("✗ Profile '%s' update failed: %v\n", name, err)
+			logger.Error().Err(err).Str("name", name).Msg("Profile update failed")
+			failed++
+			continue
+		}
 		fmt.Printf("✓ Profile '%s' updated successfully\n", name)
+		activeUpdated = activeUpdated || name == active
+	}
+
+	// 当前激活的 profile 更新后重新应用到主配置，运行中的服务随之重载
+	if activeUpdated {
+		if err := activateProfile(store, active); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Active profile '%s' re-applied\n", active)
+		if err := reloadIfRunning(); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d profile(s) failed to update", failed, len(names))
+	}
+
+	return nil
+}
+
+func runProfileSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	store := newProfileStore()
+
+	// 持有锁时重新读取并修改，不会和同时进行的订阅刷新互相覆盖
+	filterChanged := false
+	profile, err := store.Modify(name, func(profile *subscription.Profile) error {
+		if cmd.Flags().Changed("interval") {
+			if profileSetInterval < 0 {
+				return fmt.Errorf("invalid interval: %s", profileSetInterval)
+			}
+			profile.Interval = int(profileSetInterval.Seconds())
+		}
+
+		if len(profileSourceIntervals) > 0 {
+			if err := applySourceIntervals(profile.Sources, profileSourceIntervals); err != nil {
+				return err
+			}
+		}
+
+		if cmd.Flags().Changed("history") {
+			if profileHistoryLimit < 0 {
+				return fmt.Errorf("invalid history limit: %d", profileHistoryLimit)
+			}
+			profile.HistoryLimit = profileHistoryLimit
+		}
+
+		filter, err := filterFromFlags(cmd, profile.Filter)
+		if err != nil {
+			return err
+		}
+		filterChanged = filter != profile.Filter
+		profile.Filter = filter
+
+		fetch, err := fetchFromFlags(cmd, profile.Fetch)
+		if err != nil {
+			return err
+		}
+		profile.Fetch = fetch
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Profile '%s' updated\n", name)
+	if filterChanged {
+		fmt.Printf("  Run 'clash-fish profile update %s' to apply the new filter\n", name)
+	}
+	logger.Info().Str("name", name).Int("interval", profile.Interval).Msg("Profile settings changed")
+
+	return nil
+}
+
+func runProfileShow(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	store := newProfileStore()
+
+	profile, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+	if !profileShowSecrets {
+		subscription.RedactProfile(profile)
+	}
+
+	fmt.Printf("=== Profile '%s' ===\n", name)
+	if profile.IsAggregate() {
+		fmt.Println("Sources:")
+		for _, src := range profile.Sources {
+			fmt.Printf("  - %s (%s: %s)\n", src.Name, src.Kind(), src.Location())
+		}
 	} else {
-		fmt.Println("✓ All profiles updated successfully")
+		fmt.Printf("%-9s %s\n", locationLabel(profile)+":", profile.Location())
+	}
+	fmt.Printf("Updated:  %s\n", profile.UpdatedAt.Format("2006-01-02 15:04:05"))
+
+	// 下载选项
+	if o := profile.Fetch; !o.IsEmpty() {
+		fmt.Println("\nFetch:")
+		printFilterField("User-Agent", o.UserAgent)
+		for key, value := range o.Headers {
+			fmt.Printf("  %-15s %s: %s\n", "Header:", key, value)
+		}
+		if o.ViaProxy {
+			printFilterField("Via proxy", "yes")
+		}
+		for _, mirror := range o.Mirrors {
+			printFilterField("Mirror", mirror)
+		}
+		printFilterField("Retries", strconv.Itoa(o.GetRetries()))
+	}
+
+	// 过滤设置
+	if f := profile.Filter; !f.IsEmpty() {
+		fmt.Println("\nFilter:")
+		printFilterField("Include name", f.IncludeName)
+		printFilterField("Exclude name", f.ExcludeName)
+		printFilterField("Include type", f.IncludeType)
+		printFilterField("Exclude type", f.ExcludeType)
+		printFilterField("Include server", f.IncludeServer)
+		printFilterField("Exclude server", f.ExcludeServer)
+		for _, r := range f.Rename {
+			fmt.Printf("  Rename:         %s => %s\n", r.Pattern, r.Replace)
+		}
+	}
+
+	data, err := store.ReadConfig(name)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return err
 	}
 
+	fmt.Printf("\nNodes (%d):\n", len(cfg.Proxies))
+	for _, p := range cfg.Proxies {
+		fmt.Printf("  %-40s %-10s %s:%d\n", p.Name, p.Type, p.Server, p.Port)
+	}
+
+	fmt.Printf("\nProxy Groups (%d):\n", len(cfg.ProxyGroups))
+	for _, g := range cfg.ProxyGroups {
+		fmt.Printf("  %-40s %-10s %d members\n", g.Name, g.Type, len(g.Proxies))
+	}
+
+	fmt.Printf("\nRules: %d\n", len(cfg.Rules))
+
 	return nil
 }
 
@@ -113,27 +481,139 @@ func runProfileSwitch(cmd *cobra.Command, args []string) error {
 
 	logger.Info().Str("name", name).Msg("Switching profile...")
 
-	// TODO: 实现 profile 切换逻辑
-	// 1. 验证 profile 存在
-	// 2. 复制 profile 配置到主配置
-	// 3. 更新当前激活的 profile 标记
-	// 4. 如果服务正在运行，重新加载配置
+	if err := activateProfile(newProfileStore(), name); err != nil {
+		return err
+	}
 
 	fmt.Printf("✓ Switched to profile '%s'\n", name)
 	logger.Info().Str("name", name).Msg("Profile switched")
 
+	return reloadIfRunning()
+}
+
+func runProfileHistory(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	store := newProfileStore()
+
+	revisions, err := store.History(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("=== History of '%s' ===\n", name)
+	if len(revisions) == 0 {
+		fmt.Println("No previous versions recorded.")
+		return nil
+	}
+
+	fmt.Printf("%-6s %-20s %s\n", "REV", "REPLACED AT", "NODES")
+	for _, r := range revisions {
+		nodes := "-"
+		if data, err := store.ReadRevision(name, r.Rev); err == nil {
+			if cfg, err := config.Parse(data); err == nil {
+				nodes = strconv.Itoa(len(cfg.Proxies))
+			}
+		}
+		fmt.Printf("%-6d %-20s %s\n", r.Rev, r.SavedAt.Format("2006-01-02 15:04:05"), nodes)
+	}
+
 	return nil
 }
 
+func runProfileDiff(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	store := newProfileStore()
+
+	// 默认与最近一个历史版本比较
+	var rev int
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid revision: %s", args[1])
+		}
+		rev = n
+	} else {
+		revisions, err := store.History(name)
+		if err != nil {
+			return err
+		}
+		if len(revisions) == 0 {
+			fmt.Printf("Profile '%s' has no previous versions\n", name)
+			return nil
+		}
+		rev = revisions[0].Rev
+	}
+
+	oldData, err := store.ReadRevision(name, rev)
+	if err != nil {
+		return err
+	}
+	newData, err := store.ReadConfig(name)
+	if err != nil {
+		return err
+	}
+
+	diff, err := config.CompareData(oldData, newData)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("=== '%s': rev %d → current ===\n", name, rev)
+	printConfigDiff(diff)
+
+	return nil
+}
+
+func runProfileRollback(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	rev, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid revision: %s", args[1])
+	}
+
+	logger.Info().Str("name", name).Int("rev", rev).Msg("Rolling back profile...")
+
+	store := newProfileStore()
+	if _, err := store.Rollback(name, rev); err != nil {
+		return fmt.Errorf("failed to roll back profile: %w", err)
+	}
+
+	fmt.Printf("✓ Profile '%s' rolled back to rev %d\n", name, rev)
+	logger.Info().Str("name", name).Int("rev", rev).Msg("Profile rolled back")
+
+	// 当前激活的 profile 需要同步到主配置
+	active, err := store.GetActive()
+	if err != nil {
+		return fmt.Errorf("failed to read active profile: %w", err)
+	}
+	if active != name {
+		return nil
+	}
+	if err := activateProfile(store, name); err != nil {
+		return err
+	}
+	return reloadIfRunning()
+}
+
 func runProfileDelete(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
 	logger.Info().Str("name", name).Msg("Deleting profile...")
 
-	// TODO: 实现 profile 删除逻辑
-	// 1. 验证 profile 存在
-	// 2. 检查是否是当前激活的 profile
-	// 3. 删除 profile 文件
+	store := newProfileStore()
+
+	// 不允许删除当前激活的 profile
+	active, err := store.GetActive()
+	if err != nil {
+		return fmt.Errorf("failed to read active profile: %w", err)
+	}
+	if active == name {
+		return fmt.Errorf("profile '%s' is active, switch to another profile first", name)
+	}
+
+	if err := store.Delete(name); err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
 
 	fmt.Printf("✓ Profile '%s' deleted successfully\n", name)
 	logger.Info().Str("name", name).Msg("Profile deleted")
@@ -141,12 +621,509 @@ func runProfileDelete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runProfileExport(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	store := newProfileStore()
+
+	if !store.Exists(name) {
+		return fmt.Errorf("%w: %s", subscription.ErrProfileNotFound, name)
+	}
+
+	output := profileOutput
+	if output == "" {
+		output = name + ".tar.gz"
+	}
+
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer f.Close()
+
+	mgr := config.NewManager(configDir)
+	manifest, err := store.Export(name, f, &subscription.ExportOptions{
+		BaseDir:   mgr.GetConfigDir(),
+		MixinPath: mgr.GetMixinPath(),
+		Redact:    profileRedact,
+	})
+	if err != nil {
+		f.Close()
+		os.Remove(output)
+		return fmt.Errorf("failed to export profile: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	fmt.Printf("✓ Profile '%s' exported to %s\n", name, output)
+	if len(manifest.RuleFiles) > 0 {
+		fmt.Printf("  Rule files: %d\n", len(manifest.RuleFiles))
+	}
+	for _, rule := range manifest.Rejected {
+		fmt.Printf("  ⚠ Rule file of provider '%s' skipped, outside %s: %s\n", rule.Provider, mgr.GetConfigDir(), rule.Path)
+	}
+	if manifest.Redacted {
+		fmt.Println("  Passwords, UUIDs and subscription tokens have been redacted")
+	}
+
+	return nil
+}
+
+func runProfileImport(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+
+	bundle, err := subscription.ReadBundle(f)
+	if err != nil {
+		return err
+	}
+
+	// 覆盖层在导入 profile 之前检查，被拒绝时不留下导入一半的内容
+	if profileImportMixin && bundle.Mixin != nil {
+		if err := subscription.CheckSecretRefs(bundle.Mixin); err != nil {
+			return fmt.Errorf("bundle mixin rejected: %w", err)
+		}
+	}
+
+	store := newProfileStore()
+	profile, err := store.Import(bundle, profileImportName)
+	if err != nil {
+		return fmt.Errorf("failed to import profile: %w", err)
+	}
+
+	fmt.Printf("✓ Profile '%s' imported\n", profile.Name)
+	if len(bundle.Manifest.RuleFiles) > 0 {
+		fmt.Printf("  Rule files: %d\n", len(bundle.Manifest.RuleFiles))
+	}
+
+	// 覆盖层是全局的，只有显式指定 --mixin 时才写入
+	if bundle.Mixin != nil {
+		if !profileImportMixin {
+			fmt.Println("  Bundle mixin skipped (use --mixin to replace the local mixin overlay)")
+		} else {
+			mixinPath := config.NewManager(configDir).GetMixinPath()
+			if err := utils.WriteFileAtomic(mixinPath, bundle.Mixin, utils.PrivateFileMode); err != nil {
+				return fmt.Errorf("failed to write mixin: %w", err)
+			}
+			fmt.Printf("  Mixin overlay written to %s\n", mixinPath)
+		}
+	}
+	for _, rule := range bundle.Manifest.Rejected {
+		fmt.Printf("  ⚠ Rule file of provider '%s' was not exported: %s\n", rule.Provider, rule.Path)
+	}
+
+	for _, src := range profile.Sources {
+		if src.Profile != "" && !store.Exists(src.Profile) {
+			fmt.Printf("  ⚠ Source '%s' references missing profile '%s'\n", src.Name, src.Profile)
+		}
+		if src.File != "" {
+			if _, err := os.Stat(src.File); err != nil {
+				fmt.Printf("  ⚠ Source '%s' references missing file %s\n", src.Name, src.File)
+			}
+		}
+	}
+	if bundle.Manifest.Redacted {
+		fmt.Println("  ⚠ Bundle is redacted: restore credentials and subscription URLs before use")
+		fmt.Println("    Auto update is disabled for redacted subscription URLs")
+	}
+
+	logger.Info().Str("name", profile.Name).Msg("Profile imported")
+	return nil
+}
+
+// activateProfile 验证 profile 叠加覆盖层后的实际配置，原子写入为主配置并标记为当前激活
+func activateProfile(store *subscription.Store, name string) error {
+	data, err := store.ReadConfig(name)
+	if err != nil {
+		return err
+	}
+
+	// 校验叠加本地覆盖层和设置覆盖后的实际配置，profile 可以依赖覆盖层提供端口、模式等设置
+	mgr := config.NewManager(configDir)
+	mgr.SetOverrides(settings.Overrides())
+	if err := mgr.ValidateEffective(data); err != nil {
+		return fmt.Errorf("profile '%s' is invalid: %w", name, err)
+	}
+
+	if err := os.MkdirAll(mgr.GetConfigDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := mgr.SaveRaw(data); err != nil {
+		return fmt.Errorf("failed to apply profile: %w", err)
+	}
+
+	if err := store.SetActive(name); err != nil {
+		return fmt.Errorf("failed to mark active profile: %w", err)
+	}
+	return nil
+}
+
+// reloadIfRunning 服务运行中时通知其热重载
+func reloadIfRunning() error {
+	manager := proxy.NewManager(configDir)
+	if !manager.IsRunning() {
+		return nil
+	}
+
+	if err := manager.Reload(); err != nil {
+		return fmt.Errorf("configuration written but reload failed: %w", err)
+	}
+	fmt.Println("  Running service reloaded")
+	return nil
+}
+
+// printConfigDiff 打印配置差异
+func printConfigDiff(diff *config.ConfigDiff) {
+	if diff.IsEmpty() {
+		fmt.Println("No changes.")
+		return
+	}
+
+	if len(diff.Settings) > 0 {
+		fmt.Printf("Settings: %d changed\n", len(diff.Settings))
+		for _, s := range diff.Settings {
+			switch {
+			case s.From == "":
+				fmt.Printf("  + %s: %s\n", s.Key, s.To)
+			case s.To == "":
+				fmt.Printf("  - %s: %s\n", s.Key, s.From)
+			default:
+				fmt.Printf("  ~ %s: %s → %s\n", s.Key, s.From, s.To)
+			}
+		}
+	}
+
+	fmt.Printf("Nodes: +%d -%d ~%d\n", len(diff.ProxiesAdded), len(diff.ProxiesRemoved), len(diff.ProxiesModified))
+	for _, name := range diff.ProxiesAdded {
+		fmt.Printf("  + %s\n", name)
+	}
+	for _, name := range diff.ProxiesRemoved {
+		fmt.Printf("  - %s\n", name)
+	}
+	for _, p := range diff.ProxiesModified {
+		fmt.Printf("  ~ %s (%s)\n", p.Name, strings.Join(p.Fields, ", "))
+	}
+
+	if len(diff.GroupsAdded)+len(diff.GroupsRemoved)+len(diff.GroupsChanged) > 0 {
+		fmt.Printf("Groups: +%d -%d ~%d\n", len(diff.GroupsAdded), len(diff.GroupsRemoved), len(diff.GroupsChanged))
+		for _, name := range diff.GroupsAdded {
+			fmt.Printf("  + %s\n", name)
+		}
+		for _, name := range diff.GroupsRemoved {
+			fmt.Printf("  - %s\n", name)
+		}
+		for _, g := range diff.GroupsChanged {
+			fmt.Printf("  ~ %s\n", g.Name)
+			if g.TypeFrom != "" {
+				fmt.Printf("      type: %s → %s\n", g.TypeFrom, g.TypeTo)
+			}
+			for _, m := range g.MembersAdded {
+				fmt.Printf("      + %s\n", m)
+			}
+			for _, m := range g.MembersRemoved {
+				fmt.Printf("      - %s\n", m)
+			}
+			if g.Reordered {
+				fmt.Println("      members reordered")
+			}
+		}
+	}
+
+	fmt.Printf("Rules: +%d -%d, %d moved\n", len(diff.RulesAdded), len(diff.RulesRemoved), len(diff.RulesMoved))
+	for _, rule := range diff.RulesAdded {
+		fmt.Printf("  + %s\n", rule)
+	}
+	for _, rule := range diff.RulesRemoved {
+		fmt.Printf("  - %s\n", rule)
+	}
+	for _, m := range diff.RulesMoved {
+		fmt.Printf("  ↕ %s (#%d → #%d)\n", m.Rule, m.From+1, m.To+1)
+	}
+}
+
+// parseLocalPath 解析本地文件位置（路径或 file:// 链接），返回绝对路径；HTTP(S) 链接返回 false
+func parseLocalPath(location string) (string, bool, error) {
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return "", false, nil
+	case strings.HasPrefix(location, "file://"):
+		u, err := url.Parse(location)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid file url: %w", err)
+		}
+		if u.Host != "" && u.Host != "localhost" {
+			return "", false, fmt.Errorf("file url must refer to a local path: %s", location)
+		}
+		location = u.Path
+	case strings.Contains(location, "://"):
+		return "", false, fmt.Errorf("unsupported location: %s", location)
+	}
+
+	path, err := filepath.Abs(location)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid file path: %w", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return path, true, nil
+}
+
+// locationLabel profile 位置的显示标签
+func locationLabel(p *subscription.Profile) string {
+	switch p.Kind() {
+	case "file":
+		return "File"
+	case "stdin":
+		return "Source"
+	default:
+		return "URL"
+	}
+}
+
+// parseSources 解析 --source name=spec 参数，spec 为订阅链接、file:<path> 或 profile:<name>
+func parseSources(specs, intervals []string) ([]*subscription.Source, error) {
+	var sources []*subscription.Source
+	for _, spec := range specs {
+		name, location, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || location == "" {
+			return nil, fmt.Errorf("invalid --source %q (expected name=url|file:<path>|profile:<name>)", spec)
+		}
+
+		src := &subscription.Source{Name: name}
+		switch {
+		case strings.HasPrefix(location, "profile:"):
+			src.Profile = strings.TrimPrefix(location, "profile:")
+		case strings.HasPrefix(location, "file:"):
+			path, err := filepath.Abs(strings.TrimPrefix(location, "file:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid source file: %w", err)
+			}
+			src.File = path
+		default:
+			src.URL = location
+			src.Interval = int(profileInterval.Seconds())
+		}
+		sources = append(sources, src)
+	}
+
+	if err := applySourceIntervals(sources, intervals); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// applySourceIntervals 解析 --source-interval name=duration 参数并设置来源更新间隔
+func applySourceIntervals(sources []*subscription.Source, intervals []string) error {
+	for _, spec := range intervals {
+		name, value, ok := strings.Cut(spec, "=")
+		if !ok {
+			return fmt.Errorf("invalid --source-interval %q (expected name=duration)", spec)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid --source-interval %q: bad duration", spec)
+		}
+
+		found := false
+		for _, src := range sources {
+			if src.Name == name {
+				src.Interval = int(d.Seconds())
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown source '%s'", name)
+		}
+	}
+	return nil
+}
+
+// filterFromFlags 根据命令行标志生成过滤设置，未修改任何过滤标志时返回 current
+func filterFromFlags(cmd *cobra.Command, current *subscription.Filter) (*subscription.Filter, error) {
+	flags := map[string]*string{
+		"include":        &profileFilter.IncludeName,
+		"exclude":        &profileFilter.ExcludeName,
+		"include-type":   &profileFilter.IncludeType,
+		"exclude-type":   &profileFilter.ExcludeType,
+		"include-server": &profileFilter.IncludeServer,
+		"exclude-server": &profileFilter.ExcludeServer,
+	}
+
+	filter := &subscription.Filter{}
+	if current != nil {
+		*filter = *current
+	}
+	targets := map[string]*string{
+		"include":        &filter.IncludeName,
+		"exclude":        &filter.ExcludeName,
+		"include-type":   &filter.IncludeType,
+		"exclude-type":   &filter.ExcludeType,
+		"include-server": &filter.IncludeServer,
+		"exclude-server": &filter.ExcludeServer,
+	}
+
+	changed := false
+	for name, value := range flags {
+		if cmd.Flags().Changed(name) {
+			*targets[name] = *value
+			changed = true
+		}
+	}
+
+	if cmd.Flags().Changed("rename") {
+		filter.Rename = nil
+		for _, spec := range profileRename {
+			pattern, replace, ok := strings.Cut(spec, "=>")
+			if !ok || pattern == "" {
+				return nil, fmt.Errorf("invalid --rename %q (expected pattern=>replacement)", spec)
+			}
+			filter.Rename = append(filter.Rename, subscription.RenameRule{Pattern: pattern, Replace: replace})
+		}
+		changed = true
+	}
+
+	if !changed {
+		return current, nil
+	}
+	if err := filter.Compile(); err != nil {
+		return nil, err
+	}
+	if filter.IsEmpty() {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// fetchFromFlags 根据命令行标志生成下载选项，未修改任何下载标志时返回 current
+func fetchFromFlags(cmd *cobra.Command, current *subscription.FetchOptions) (*subscription.FetchOptions, error) {
+	fetch := &subscription.FetchOptions{}
+	if current != nil {
+		*fetch = *current
+	}
+
+	flags := cmd.Flags()
+	changed := false
+	if flags.Changed("user-agent") {
+		fetch.UserAgent = profileFetch.UserAgent
+		changed = true
+	}
+	if flags.Changed("header") {
+		fetch.Headers = nil
+		for _, spec := range profileHeaders {
+			key, value, ok := strings.Cut(spec, ":")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid --header %q (expected 'Name: value')", spec)
+			}
+			if fetch.Headers == nil {
+				fetch.Headers = make(map[string]string)
+			}
+			fetch.Headers[key] = strings.TrimSpace(value)
+		}
+		changed = true
+	}
+	if flags.Changed("via-proxy") {
+		fetch.ViaProxy = profileFetch.ViaProxy
+		changed = true
+	}
+	if flags.Changed("mirror") {
+		fetch.Mirrors = profileFetch.Mirrors
+		changed = true
+	}
+	if flags.Changed("retries") {
+		fetch.Retries = profileFetch.Retries
+		changed = true
+	}
+
+	if !changed {
+		return current, nil
+	}
+	if fetch.IsEmpty() {
+		return nil, nil
+	}
+	return fetch, nil
+}
+
+// addFetchFlags 为命令添加下载选项标志
+func addFetchFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&profileFetch.UserAgent, "user-agent", "", "User-Agent sent when downloading (e.g. clash.meta)")
+	cmd.Flags().StringArrayVar(&profileHeaders, "header", nil, "extra request header 'Name: value' (repeatable)")
+	cmd.Flags().BoolVar(&profileFetch.ViaProxy, "via-proxy", false, "download through the local HTTP proxy port")
+	cmd.Flags().StringArrayVar(&profileFetch.Mirrors, "mirror", nil, "fallback mirror URL tried when the main URL fails (repeatable)")
+	cmd.Flags().IntVar(&profileFetch.Retries, "retries", 0, "retries per URL with backoff (0 for default, -1 to disable)")
+}
+
+// newImporter 创建订阅导入器，via-proxy 下载使用主配置中的 HTTP 代理端口，
+// 保存前检查 profile 叠加本地覆盖层后的引用关系，完整校验留到应用时
+func newImporter(store *subscription.Store) *subscription.Importer {
+	importer := subscription.NewImporter(store, subscription.NewDownloader(nil))
+	mgr := config.NewManager(configDir)
+	mgr.SetOverrides(settings.Overrides())
+	importer.SetValidator(mgr.ValidateReferences)
+	if cfg, err := mgr.Load(); err == nil {
+		importer.SetLocalProxy(subscription.LocalProxyAddr(cfg))
+	}
+	return importer
+}
+
+// addFilterFlags 为命令添加节点过滤标志
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&profileFilter.IncludeName, "include", "", "keep only nodes whose name matches this regex")
+	cmd.Flags().StringVar(&profileFilter.ExcludeName, "exclude", "", "drop nodes whose name matches this regex")
+	cmd.Flags().StringVar(&profileFilter.IncludeType, "include-type", "", "keep only nodes whose type matches this regex")
+	cmd.Flags().StringVar(&profileFilter.ExcludeType, "exclude-type", "", "drop nodes whose type matches this regex")
+	cmd.Flags().StringVar(&profileFilter.IncludeServer, "include-server", "", "keep only nodes whose server matches this regex")
+	cmd.Flags().StringVar(&profileFilter.ExcludeServer, "exclude-server", "", "drop nodes whose server matches this regex")
+	cmd.Flags().StringArrayVar(&profileRename, "rename", nil, "rename nodes with pattern=>replacement (repeatable)")
+}
+
+// printFilterField 打印非空的过滤设置
+func printFilterField(label, value string) {
+	if value != "" {
+		fmt.Printf("  %-15s %s\n", label+":", value)
+	}
+}
+
+// newProfileStore 创建配置目录下的 profile 存储
+func newProfileStore() *subscription.Store {
+	return subscription.NewStore(config.NewManager(configDir).GetProfilesDir())
+}
+
 func init() {
+	// 添加标志
+	profileAddCmd.Flags().DurationVar(&profileInterval, "interval", 24*time.Hour, "auto update interval (0 to disable)")
+	profileAddCmd.Flags().StringArrayVar(&profileSources, "source", nil, "merge source name=url|file:<path>|profile:<name> (repeatable)")
+	profileAddCmd.Flags().StringArrayVar(&profileSourceIntervals, "source-interval", nil, "per-source update interval name=duration (repeatable)")
+	profileSetCmd.Flags().DurationVar(&profileSetInterval, "interval", 0, "auto update interval (0 to disable)")
+	profileSetCmd.Flags().StringArrayVar(&profileSourceIntervals, "source-interval", nil, "per-source update interval name=duration (repeatable)")
+	addFilterFlags(profileAddCmd)
+	addFilterFlags(profileSetCmd)
+	addFetchFlags(profileAddCmd)
+	addFetchFlags(profileSetCmd)
+	profileExportCmd.Flags().StringVarP(&profileOutput, "output", "o", "", "bundle path (default <name>.tar.gz)")
+	profileExportCmd.Flags().BoolVar(&profileRedact, "redact", false, "redact passwords, UUIDs and subscription tokens")
+	profileImportCmd.Flags().StringVar(&profileImportName, "name", "", "import under a different profile name")
+	profileImportCmd.Flags().BoolVar(&profileImportMixin, "mixin", false, "replace the local mixin overlay with the bundled one")
+	profileListCmd.Flags().BoolVar(&profileShowSecrets, "show-secrets", false, "show subscription URLs and request headers without redaction")
+	profileShowCmd.Flags().BoolVar(&profileShowSecrets, "show-secrets", false, "show subscription URLs and request headers without redaction")
+	profileSetCmd.Flags().IntVar(&profileHistoryLimit, "history", subscription.DefaultHistoryLimit, "number of previous versions to keep")
+
 	// 添加子命令
 	profileCmd.AddCommand(profileListCmd)
 	profileCmd.AddCommand(profileAddCmd)
 	profileCmd.AddCommand(profileUpdateCmd)
+	profileCmd.AddCommand(profileSetCmd)
+	profileCmd.AddCommand(profileShowCmd)
 	profileCmd.AddCommand(profileSwitchCmd)
+	profileCmd.AddCommand(profileHistoryCmd)
+	profileCmd.AddCommand(profileDiffCmd)
+	profileCmd.AddCommand(profileRollbackCmd)
+	profileCmd.AddCommand(profileExportCmd)
+	profileCmd.AddCommand(profileImportCmd)
 	profileCmd.AddCommand(profileDeleteCmd)
 
 	// 添加到根命令
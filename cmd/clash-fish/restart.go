@@ -26,6 +26,7 @@ func runRestart(cmd *cobra.Command, args []string) error {
 
 	// 创建代理管理器
 	manager := proxy.NewManager(configDir)
+	manager.SetOverrides(settings.Overrides())
 
 	// 重启服务
 	if err := manager.Restart(); err != nil {
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ruleLintFormat string
+	ruleLintFile   string
+)
+
+var ruleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Inspect routing rules",
+	Long:  `Inspect the routing rules in the configuration.`,
+}
+
+var ruleLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check rules for mistakes",
+	Long: `Parse every entry in rules and report:
+  - unknown rule types and malformed values (CIDRs, ports, regexes)
+  - misplaced options such as no-resolve
+  - rules after MATCH, which can never be reached
+  - rules shadowed by an earlier, broader rule
+  - duplicate rules`,
+	RunE: runRuleLint,
+}
+
+func runRuleLint(cmd *cobra.Command, args []string) error {
+	if ruleLintFormat != "text" && ruleLintFormat != "json" {
+		return fmt.Errorf("invalid --format %q (must be text or json)", ruleLintFormat)
+	}
+
+	path := ruleLintFile
+	if path == "" {
+		mgr := config.NewManager(configDir)
+		if !mgr.Exists() {
+			return fmt.Errorf("configuration not found, run 'clash-fish config init' first")
+		}
+		path = mgr.GetConfigPath()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read rules: %w", err)
+	}
+
+	issues, count, err := config.LintData(data)
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+	valid := !config.HasErrors(issues)
+
+	if ruleLintFormat == "json" {
+		out, err := json.MarshalIndent(struct {
+			File   string         `json:"file"`
+			Rules  int            `json:"rules"`
+			Valid  bool           `json:"valid"`
+			Issues []config.Issue `json:"issues"`
+		}{path, count, valid, append([]config.Issue{}, issues...)}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode result: %w", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		printIssues(issues)
+		warnings := 0
+		for _, issue := range issues {
+			if issue.Severity == config.SeverityWarning {
+				warnings++
+			}
+		}
+		fmt.Printf("%d rules checked: %d error(s), %d warning(s)\n", count, len(issues)-warnings, warnings)
+	}
+
+	if !valid {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = ruleLintFormat == "json"
+		return fmt.Errorf("rules contain errors")
+	}
+
+	return nil
+}
+
+func init() {
+	ruleLintCmd.Flags().StringVar(&ruleLintFormat, "format", "text", "output format: text or json")
+	ruleLintCmd.Flags().StringVarP(&ruleLintFile, "file", "f", "", "lint this config file instead of the active configuration")
+
+	// 添加子命令
+	ruleCmd.AddCommand(ruleLintCmd)
+
+	// 添加到根命令
+	rootCmd.AddCommand(ruleCmd)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage secrets referenced from the configuration",
+	Long: `Manage the encrypted secret store in the config directory.
+
+Credential fields (password, uuid, token, ...) in config.yaml and profiles can
+hold a reference instead of the plaintext value. References are resolved only
+when the service builds the configuration it loads, so the files themselves can
+be committed safely:
+
+  password: keyring:hk-password   # from this secret store
+  password: env:HK_PASSWORD       # from an environment variable
+  password: file:/run/secrets/hk  # from a file (trailing newline removed)
+
+The store is encrypted with a key kept in ` + config.SecretKeyFileName + ` (or the ` + config.SecretKeyEnv + `
+environment variable). Do not commit either file.`,
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set <name> [value]",
+	Short: "Store a secret",
+	Long: `Store a secret under a name, replacing any existing value.
+Without a value argument the value is read from stdin, which keeps it out of
+the shell history.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runSecretSet,
+}
+
+var secretListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored secret names",
+	Args:  cobra.NoArgs,
+	RunE:  runSecretList,
+}
+
+var secretRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSecretRm,
+}
+
+func runSecretSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	var value string
+	if len(args) > 1 {
+		value = args[1]
+	} else {
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		}
+		line, err := bufio.NewReader(io.LimitReader(os.Stdin, maxStdinSize)).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read value: %w", err)
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	if value == "" {
+		return fmt.Errorf("secret value is empty")
+	}
+
+	store := config.NewSecretStore(configDir)
+	if err := store.Set(name, value); err != nil {
+		return fmt.Errorf("failed to store secret: %w", err)
+	}
+
+	fmt.Printf("✓ Secret '%s' stored\n", name)
+	fmt.Printf("  Reference it as: keyring:%s\n", name)
+	return nil
+}
+
+func runSecretList(cmd *cobra.Command, args []string) error {
+	names, err := config.NewSecretStore(configDir).List()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No secrets stored")
+		fmt.Println("Use 'clash-fish secret set <name>' to add one")
+		return nil
+	}
+
+	fmt.Println("=== Secrets ===")
+	for _, name := range names {
+		fmt.Printf("  %s\n", name)
+	}
+	return nil
+}
+
+func runSecretRm(cmd *cobra.Command, args []string) error {
+	if err := config.NewSecretStore(configDir).Delete(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Secret '%s' removed\n", args[0])
+	return nil
+}
+
+func init() {
+	// 添加子命令
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretRmCmd)
+
+	// 添加到根命令
+	rootCmd.AddCommand(secretCmd)
+}
@@ -29,6 +29,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	// 创建代理管理器
 	manager := proxy.NewManager(configDir)
+	manager.SetOverrides(settings.Overrides())
 
 	// 启动服务
 	if err := manager.Start(); err != nil {
@@ -41,10 +42,19 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	// 设置信号处理
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	// 等待退出信号
-	sig := <-sigCh
+	// 等待退出信号，SIGHUP 触发配置重载
+	var sig os.Signal
+	for sig = range sigCh {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		logger.Info().Msg("Received SIGHUP, reloading configuration...")
+		if err := manager.ReloadEngine(); err != nil {
+			logger.Error().Err(err).Msg("Failed to reload configuration")
+		}
+	}
 	logger.Info().Str("signal", sig.String()).Msg("Received signal, shutting down...")
 
 	fmt.Println("\nStopping Clash-Fish...")
@@ -61,5 +71,10 @@ func runStart(cmd *cobra.Command, args []string) error {
 }
 
 func init() {
+	// 覆盖 config.yaml 中的设置（优先级高于 CLASH_FISH_* 环境变量）
+	startCmd.Flags().Int("port", 0, "HTTP proxy port")
+	startCmd.Flags().Int("socks-port", 0, "SOCKS5 proxy port")
+	startCmd.Flags().String("controller", "", "external controller address")
+
 	rootCmd.AddCommand(startCmd)
 }
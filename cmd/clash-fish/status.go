@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/clash-fish/clash-fish/internal/config"
 	"github.com/clash-fish/clash-fish/internal/proxy"
+	"github.com/clash-fish/clash-fish/internal/subscription"
 	"github.com/clash-fish/clash-fish/internal/system"
 	"github.com/spf13/cobra"
 )
@@ -60,6 +62,20 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println("            Run 'clash-fish config init' to create configuration")
 	}
 
+	// 当前 profile 的订阅信息
+	store := newProfileStore()
+	if active, err := store.GetActive(); err == nil && active != "" {
+		fmt.Printf("Profile:    %s\n", active)
+		if profile, err := store.Get(active); err == nil && profile.UserInfo != nil {
+			fmt.Printf("  Traffic:  %s\n", profile.UserInfo.FormatTraffic())
+			fmt.Printf("  Expires:  %s\n", profile.UserInfo.FormatExpire(time.Now()))
+			for _, warning := range profile.UserInfo.Warnings(time.Now()) {
+				fmt.Printf("  ⚠ %s\n", warning)
+			}
+			subscription.WarnUsage(profile)
+		}
+	}
+
 	return nil
 }
 
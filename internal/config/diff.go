@@ -0,0 +1,363 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigDiff 两份配置之间的语义差异
+type ConfigDiff struct {
+	Settings        []SettingChange
+	ProxiesAdded    []string
+	ProxiesRemoved  []string
+	ProxiesModified []ProxyChange
+	GroupsAdded     []string
+	GroupsRemoved   []string
+	GroupsChanged   []GroupChange
+	RulesAdded      []string
+	RulesRemoved    []string
+	RulesMoved      []RuleMove
+}
+
+// SettingChange 设置项的变化，From/To 为空表示新增或删除
+type SettingChange struct {
+	Key  string
+	From string
+	To   string
+}
+
+// ProxyChange 同名代理的字段变化
+type ProxyChange struct {
+	Name   string
+	Fields []string
+}
+
+// GroupChange 同名代理组的变化
+type GroupChange struct {
+	Name           string
+	TypeFrom       string
+	TypeTo         string
+	MembersAdded   []string
+	MembersRemoved []string
+	Reordered      bool
+}
+
+// RuleMove 两份配置中都存在但相对顺序改变的规则
+type RuleMove struct {
+	Rule string
+	From int
+	To   int
+}
+
+// listKeys 单独按元素比较的顶层列表，其余字段作为设置项比较
+var listKeys = map[string]bool{
+	"proxies":      true,
+	"proxy-groups": true,
+	"rules":        true,
+	VersionKey:     true, // 格式版本不是设置项
+}
+
+// Compare 比较两份配置：代理和代理组按名称匹配，规则按内容匹配
+func Compare(from, to *Config) *ConfigDiff {
+	return compareNodes(mustEncode(from), mustEncode(to))
+}
+
+// CompareData 解析并比较两份配置内容，包括 Config 结构之外的字段
+func CompareData(from, to []byte) (*ConfigDiff, error) {
+	var a, b map[string]interface{}
+	if err := yaml.Unmarshal(from, &a); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := yaml.Unmarshal(to, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return compareNodes(a, b), nil
+}
+
+// mustEncode 将 Config 转换为通用映射；Config 总能编码，失败时返回空映射
+func mustEncode(config *Config) map[string]interface{} {
+	var m map[string]interface{}
+	data, err := yaml.Marshal(config)
+	if err == nil {
+		err = yaml.Unmarshal(data, &m)
+	}
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
+
+// compareNodes 比较两份解析为通用映射的配置
+func compareNodes(from, to map[string]interface{}) *ConfigDiff {
+	diff := &ConfigDiff{}
+
+	// 设置项：展开为点分路径逐项比较
+	fromSettings, toSettings := make(map[string]string), make(map[string]string)
+	flattenSettings("", from, fromSettings)
+	flattenSettings("", to, toSettings)
+	keys := make(map[string]bool)
+	for k := range fromSettings {
+		keys[k] = true
+	}
+	for k := range toSettings {
+		keys[k] = true
+	}
+	for _, k := range sortedKeys(keys) {
+		if fromSettings[k] != toSettings[k] {
+			diff.Settings = append(diff.Settings, SettingChange{Key: k, From: fromSettings[k], To: toSettings[k]})
+		}
+	}
+
+	// 代理：按名称匹配，同名时比较字段
+	fromProxies, fromOrder := namedItems(from["proxies"])
+	toProxies, toOrder := namedItems(to["proxies"])
+	diff.ProxiesAdded, diff.ProxiesRemoved = diffStrings(fromOrder, toOrder)
+	for _, name := range toOrder {
+		old, ok := fromProxies[name]
+		if !ok {
+			continue
+		}
+		if fields := changedFields(old, toProxies[name]); len(fields) > 0 {
+			diff.ProxiesModified = append(diff.ProxiesModified, ProxyChange{Name: name, Fields: fields})
+		}
+	}
+
+	// 代理组：按名称匹配，比较类型和成员
+	fromGroups, fromGroupOrder := namedItems(from["proxy-groups"])
+	toGroups, toGroupOrder := namedItems(to["proxy-groups"])
+	diff.GroupsAdded, diff.GroupsRemoved = diffStrings(fromGroupOrder, toGroupOrder)
+	for _, name := range toGroupOrder {
+		old, ok := fromGroups[name]
+		if !ok {
+			continue
+		}
+		next := toGroups[name]
+
+		change := GroupChange{Name: name}
+		if fmt.Sprint(old["type"]) != fmt.Sprint(next["type"]) {
+			change.TypeFrom, change.TypeTo = fmt.Sprint(old["type"]), fmt.Sprint(next["type"])
+		}
+		oldMembers, newMembers := stringList(old["proxies"]), stringList(next["proxies"])
+		change.MembersAdded, change.MembersRemoved = diffStrings(oldMembers, newMembers)
+		if len(change.MembersAdded) == 0 && len(change.MembersRemoved) == 0 {
+			change.Reordered = !reflect.DeepEqual(oldMembers, newMembers)
+		}
+
+		if change.TypeFrom != "" || len(change.MembersAdded) > 0 || len(change.MembersRemoved) > 0 || change.Reordered {
+			diff.GroupsChanged = append(diff.GroupsChanged, change)
+		}
+	}
+
+	// 规则：按内容匹配，共同规则中不在最长公共子序列里的视为移动
+	fromRules, toRules := stringList(from["rules"]), stringList(to["rules"])
+	diff.RulesAdded, diff.RulesRemoved = diffStrings(fromRules, toRules)
+	diff.RulesMoved = movedRules(fromRules, toRules)
+
+	return diff
+}
+
+// IsEmpty 检查是否没有差异
+func (d *ConfigDiff) IsEmpty() bool {
+	return len(d.Settings) == 0 &&
+		len(d.ProxiesAdded) == 0 && len(d.ProxiesRemoved) == 0 && len(d.ProxiesModified) == 0 &&
+		len(d.GroupsAdded) == 0 && len(d.GroupsRemoved) == 0 && len(d.GroupsChanged) == 0 &&
+		len(d.RulesAdded) == 0 && len(d.RulesRemoved) == 0 && len(d.RulesMoved) == 0
+}
+
+// flattenSettings 将映射展开为点分路径到值文本的映射，跳过 listKeys 中的顶层字段
+func flattenSettings(prefix string, node map[string]interface{}, out map[string]string) {
+	for key, value := range node {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		} else if listKeys[key] {
+			continue
+		}
+
+		if m, ok := value.(map[string]interface{}); ok && len(m) > 0 {
+			flattenSettings(path, m, out)
+			continue
+		}
+		out[path] = formatValue(value)
+	}
+}
+
+// formatValue 设置项值的文本形式，列表和映射使用 JSON
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// namedItems 将带 name 字段的列表转换为按名称索引的映射，同时返回名称顺序
+func namedItems(value interface{}) (map[string]map[string]interface{}, []string) {
+	items := make(map[string]map[string]interface{})
+	var order []string
+	list, _ := value.([]interface{})
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := fmt.Sprint(m["name"])
+		if _, dup := items[name]; dup {
+			continue
+		}
+		items[name] = m
+		order = append(order, name)
+	}
+	return items, order
+}
+
+// changedFields 比较两个映射，返回值不同的字段名（排序）
+func changedFields(from, to map[string]interface{}) []string {
+	keys := make(map[string]bool)
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+
+	var fields []string
+	for _, k := range sortedKeys(keys) {
+		if !reflect.DeepEqual(from[k], to[k]) {
+			fields = append(fields, k)
+		}
+	}
+	return fields
+}
+
+// stringList 将列表转换为字符串切片
+func stringList(value interface{}) []string {
+	list, _ := value.([]interface{})
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		out = append(out, fmt.Sprint(item))
+	}
+	return out
+}
+
+// sortedKeys 集合的有序键
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// movedRules 找出两份规则列表中都存在、但相对顺序改变的规则。
+// 共同规则按出现顺序配对后求最长公共子序列，不在其中的即为移动过的规则。
+func movedRules(from, to []string) []RuleMove {
+	type occurrence struct {
+		rule string
+		n    int // 同一规则的第几次出现
+	}
+
+	occurrences := func(rules []string) ([]occurrence, map[occurrence]int) {
+		seen := make(map[string]int)
+		list := make([]occurrence, len(rules))
+		index := make(map[occurrence]int, len(rules))
+		for i, r := range rules {
+			list[i] = occurrence{r, seen[r]}
+			index[list[i]] = i
+			seen[r]++
+		}
+		return list, index
+	}
+	fromList, fromIndex := occurrences(from)
+	toList, toIndex := occurrences(to)
+
+	// 只保留两边都有的规则
+	var a, b []occurrence
+	for _, o := range fromList {
+		if _, ok := toIndex[o]; ok {
+			a = append(a, o)
+		}
+	}
+	for _, o := range toList {
+		if _, ok := fromIndex[o]; ok {
+			b = append(b, o)
+		}
+	}
+
+	// 最长公共子序列
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	stable := make(map[occurrence]bool)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			stable[a[i]] = true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	var moved []RuleMove
+	for _, o := range b {
+		if !stable[o] {
+			moved = append(moved, RuleMove{Rule: o.rule, From: fromIndex[o], To: toIndex[o]})
+		}
+	}
+	return moved
+}
+
+// diffStrings 按多重集合比较两个列表，返回新增和删除的元素（保持原有顺序）
+func diffStrings(from, to []string) (added, removed []string) {
+	count := make(map[string]int, len(from))
+	for _, s := range from {
+		count[s]++
+	}
+	for _, s := range to {
+		if count[s] > 0 {
+			count[s]--
+			continue
+		}
+		added = append(added, s)
+	}
+
+	count = make(map[string]int, len(to))
+	for _, s := range to {
+		count[s]++
+	}
+	for _, s := range from {
+		if count[s] > 0 {
+			count[s]--
+			continue
+		}
+		removed = append(removed, s)
+	}
+
+	return added, removed
+}
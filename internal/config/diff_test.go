@@ -0,0 +1,141 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	ruleA = "DOMAIN-SUFFIX,a.example,PROXY"
+	ruleB = "DOMAIN-SUFFIX,b.example,DIRECT"
+	ruleC = "IP-CIDR,10.0.0.0/8,DIRECT,no-resolve"
+	ruleD = "MATCH,PROXY"
+)
+
+func TestMovedRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to []string
+		want     []RuleMove
+	}{
+		{
+			name: "identical",
+			from: []string{ruleA, ruleB, ruleC},
+			to:   []string{ruleA, ruleB, ruleC},
+		},
+		{
+			name: "identical with duplicates",
+			from: []string{ruleA, ruleB, ruleA},
+			to:   []string{ruleA, ruleB, ruleA},
+		},
+		{
+			name: "added and removed only",
+			from: []string{ruleA, ruleB, ruleD},
+			to:   []string{ruleA, ruleC, ruleD},
+		},
+		{
+			name: "moved to the front",
+			from: []string{ruleA, ruleB, ruleC},
+			to:   []string{ruleC, ruleA, ruleB},
+			want: []RuleMove{{Rule: ruleC, From: 2, To: 0}},
+		},
+		{
+			name: "swapped",
+			from: []string{ruleA, ruleB},
+			to:   []string{ruleB, ruleA},
+			want: []RuleMove{{Rule: ruleA, From: 0, To: 1}},
+		},
+		{
+			name: "duplicate removed",
+			from: []string{ruleA, ruleB, ruleA},
+			to:   []string{ruleA, ruleB},
+		},
+		{
+			name: "duplicates paired in order",
+			from: []string{ruleA, ruleB, ruleA, ruleD},
+			to:   []string{ruleA, ruleA, ruleB, ruleD},
+			want: []RuleMove{{Rule: ruleB, From: 1, To: 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := movedRules(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("movedRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareData(t *testing.T) {
+	from := []byte(`clash-fish-version: 1
+port: 7890
+dns:
+  enable: true
+proxies:
+  - {name: HK, type: ss, server: hk.example.com, port: 443}
+  - {name: US, type: ss, server: us.example.com, port: 443}
+proxy-groups:
+  - {name: PROXY, type: select, proxies: [HK, US]}
+rules:
+  - DOMAIN-SUFFIX,a.example,PROXY
+  - DOMAIN-SUFFIX,b.example,DIRECT
+  - DOMAIN-SUFFIX,a.example,PROXY
+  - MATCH,PROXY
+`)
+	to := []byte(`clash-fish-version: 2
+port: 7891
+dns:
+  enable: true
+  ipv6: false
+proxies:
+  - {name: HK, type: ss, server: hk2.example.com, port: 443}
+  - {name: JP, type: ss, server: jp.example.com, port: 443}
+proxy-groups:
+  - {name: PROXY, type: url-test, proxies: [JP, HK]}
+rules:
+  - MATCH,PROXY
+  - DOMAIN-SUFFIX,a.example,PROXY
+  - DOMAIN-SUFFIX,b.example,DIRECT
+  - DOMAIN-SUFFIX,b.example,DIRECT
+`)
+
+	diff, err := CompareData(from, to)
+	if err != nil {
+		t.Fatalf("CompareData: %v", err)
+	}
+
+	want := &ConfigDiff{
+		Settings: []SettingChange{
+			{Key: "dns.ipv6", To: "false"},
+			{Key: "port", From: "7890", To: "7891"},
+		},
+		ProxiesAdded:    []string{"JP"},
+		ProxiesRemoved:  []string{"US"},
+		ProxiesModified: []ProxyChange{{Name: "HK", Fields: []string{"server"}}},
+		GroupsChanged: []GroupChange{{
+			Name:           "PROXY",
+			TypeFrom:       "select",
+			TypeTo:         "url-test",
+			MembersAdded:   []string{"JP"},
+			MembersRemoved: []string{"US"},
+		}},
+		RulesAdded:   []string{"DOMAIN-SUFFIX,b.example,DIRECT"},
+		RulesRemoved: []string{"DOMAIN-SUFFIX,a.example,PROXY"},
+		RulesMoved:   []RuleMove{{Rule: "MATCH,PROXY", From: 3, To: 0}},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("CompareData() =\n%+v\nwant\n%+v", diff, want)
+	}
+}
+
+func TestCompareDataIdentical(t *testing.T) {
+	data := []byte("port: 7890\nrules:\n  - MATCH,DIRECT\n  - MATCH,DIRECT\n")
+	diff, err := CompareData(data, data)
+	if err != nil {
+		t.Fatalf("CompareData: %v", err)
+	}
+	if !diff.IsEmpty() {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document 已加载配置文件的原始 YAML 文档。
+// Config 结构只覆盖 mihomo 配置的一部分，保存时将结构体的修改合并回原始文档，
+// 以保留未知字段（proxy-providers、sniffer 等）、注释和字段顺序。
+type document struct {
+	root   *yaml.Node // 原始文档
+	base   *yaml.Node // 加载时 Config 结构编码后的节点，用于判断哪些字段被修改
+	indent int        // 原始文件的缩进宽度
+}
+
+// parseDocument 解析配置内容为原始文档和 Config 结构
+func parseDocument(data []byte) (*document, *Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var config Config
+	if root.Kind != 0 {
+		if err := root.Decode(&config); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	base, err := encodeNode(&config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &document{root: &root, base: base, indent: detectIndent(data)}, &config, nil
+}
+
+// merge 将 config 相对加载时的修改合并到原始文档，返回新的文件内容
+func (d *document) merge(config *Config) ([]byte, error) {
+	next, err := encodeNode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.root.Kind == yaml.DocumentNode && len(d.root.Content) > 0 {
+		d.root.Content[0] = mergeNode(d.root.Content[0], d.base, next)
+	} else {
+		d.root = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{next}}
+	}
+	d.base = next
+
+	return d.bytes()
+}
+
+// content 文档的顶层映射节点，空文档时创建
+func (d *document) content() *yaml.Node {
+	if d.root.Kind != yaml.DocumentNode || len(d.root.Content) == 0 {
+		d.root = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	return d.root.Content[0]
+}
+
+// bytes 按原始缩进编码文档
+func (d *document) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(d.indent)
+	if err := enc.Encode(d.root); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// detectIndent 检测文件使用的缩进宽度（最小的非零行首空格数），无法检测时使用 4
+func detectIndent(data []byte) int {
+	indent := 0
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " ")
+		n := len(line) - len(trimmed)
+		if n == 0 || len(bytes.TrimSpace(trimmed)) == 0 || trimmed[0] == '#' {
+			continue
+		}
+		if indent == 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent < 2 || indent > 8 {
+		return 4
+	}
+	return indent
+}
+
+// encodeNode 将值编码为 YAML 节点
+func encodeNode(v interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return &node, nil
+}
+
+// mergeNode 三方合并：将 base 到 next 的修改应用到 doc。
+// 未修改的部分原样保留 doc 节点，doc 中 base 没有的键（未知字段）不受影响。
+func mergeNode(doc, base, next *yaml.Node) *yaml.Node {
+	if doc == nil {
+		return next
+	}
+	if base != nil && nodeEqual(base, next) {
+		return doc
+	}
+
+	switch {
+	case doc.Kind == yaml.MappingNode && next.Kind == yaml.MappingNode:
+		return mergeMapping(doc, base, next)
+	case doc.Kind == yaml.SequenceNode && next.Kind == yaml.SequenceNode:
+		return mergeSequence(doc, base, next)
+	}
+
+	// 值被修改，保留原有注释
+	next.HeadComment = doc.HeadComment
+	next.LineComment = doc.LineComment
+	next.FootComment = doc.FootComment
+	return next
+}
+
+// mergeMapping 合并映射节点：更新修改的键，删除结构体中被清空的键，追加新增的键
+func mergeMapping(doc, base, next *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(next.Content); i += 2 {
+		key, value := next.Content[i], next.Content[i+1]
+		baseValue := mappingGet(base, key.Value)
+		if idx := mappingIndex(doc, key.Value); idx >= 0 {
+			doc.Content[idx+1] = mergeNode(doc.Content[idx+1], baseValue, value)
+			continue
+		}
+		// 文件中没有且未被修改的字段（结构体零值）不写入
+		if baseValue != nil && nodeEqual(baseValue, value) {
+			continue
+		}
+		doc.Content = append(doc.Content, key, value)
+	}
+
+	if base != nil && base.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(base.Content); i += 2 {
+			key := base.Content[i].Value
+			if mappingIndex(next, key) >= 0 {
+				continue
+			}
+			if idx := mappingIndex(doc, key); idx >= 0 {
+				doc.Content = append(doc.Content[:idx], doc.Content[idx+2:]...)
+			}
+		}
+	}
+
+	return doc
+}
+
+// mergeSequence 合并列表节点：带 name 字段的元素按名称匹配，其余元素按值匹配
+func mergeSequence(doc, base, next *yaml.Node) *yaml.Node {
+	used := make([]bool, len(doc.Content))
+	baseUsed := make([]bool, 0)
+	if base != nil {
+		baseUsed = make([]bool, len(base.Content))
+	}
+
+	items := make([]*yaml.Node, 0, len(next.Content))
+	for _, item := range next.Content {
+		name := nodeName(item)
+
+		var docItem, baseItem *yaml.Node
+		if name != "" {
+			docItem = takeNamed(doc.Content, used, name)
+			if base != nil {
+				baseItem = takeNamed(base.Content, baseUsed, name)
+			}
+		} else {
+			docItem = takeEqual(doc.Content, used, item)
+			if base != nil {
+				baseItem = takeEqual(base.Content, baseUsed, item)
+			}
+		}
+
+		if docItem == nil {
+			items = append(items, item)
+			continue
+		}
+		if baseItem == nil {
+			baseItem = docItem
+		}
+		items = append(items, mergeNode(docItem, baseItem, item))
+	}
+
+	doc.Content = items
+	return doc
+}
+
+// takeNamed 取出第一个未使用且 name 字段匹配的元素
+func takeNamed(nodes []*yaml.Node, used []bool, name string) *yaml.Node {
+	for i, node := range nodes {
+		if !used[i] && nodeName(node) == name {
+			used[i] = true
+			return node
+		}
+	}
+	return nil
+}
+
+// takeEqual 取出第一个未使用且内容相同的元素
+func takeEqual(nodes []*yaml.Node, used []bool, target *yaml.Node) *yaml.Node {
+	for i, node := range nodes {
+		if !used[i] && nodeEqual(node, target) {
+			used[i] = true
+			return node
+		}
+	}
+	return nil
+}
+
+// nodeName 映射节点的 name 字段值
+func nodeName(node *yaml.Node) string {
+	if name := mappingGet(node, "name"); name != nil && name.Kind == yaml.ScalarNode {
+		return name.Value
+	}
+	return ""
+}
+
+// mappingIndex 映射节点中键的位置，不存在时返回 -1
+func mappingIndex(node *yaml.Node, key string) int {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingGet 获取映射节点中键对应的值，不存在时返回 nil
+func mappingGet(node *yaml.Node, key string) *yaml.Node {
+	if idx := mappingIndex(node, key); idx >= 0 {
+		return node.Content[idx+1]
+	}
+	return nil
+}
+
+// nodeEqual 比较两个节点的内容是否相同（忽略注释和格式）
+func nodeEqual(a, b *yaml.Node) bool {
+	if a.Kind == yaml.AliasNode {
+		a = a.Alias
+	}
+	if b.Kind == yaml.AliasNode {
+		b = b.Alias
+	}
+	if a.Kind != b.Kind || len(a.Content) != len(b.Content) {
+		return false
+	}
+	if a.Kind == yaml.ScalarNode {
+		return a.Value == b.Value && a.ShortTag() == b.ShortTag()
+	}
+	for i := range a.Content {
+		if !nodeEqual(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDocument 带注释和 Config 结构之外字段的配置文件
+const testDocument = `# 家里的配置
+clash-fish-version: 2
+port: 7890 # HTTP 端口
+socks-port: 7891
+allow-lan: false
+mode: rule
+log-level: info
+external-controller: 127.0.0.1:9090
+sniffer:
+  enable: true
+  sniff:
+    TLS:
+      ports: [443, 8443]
+dns:
+  enable: true
+  listen: 0.0.0.0:53
+  enhanced-mode: fake-ip
+  fake-ip-range: 198.18.0.1/16
+  nameserver:
+    - 223.5.5.5
+  fallback: []
+  nameserver-policy:
+    '+.corp.example.com': 10.0.0.53
+proxies:
+  # 香港节点
+  - name: HK
+    type: ss
+    server: hk.example.com
+    port: 8388
+    cipher: aes-128-gcm
+    password: secret
+    tfo: true
+  - name: JP
+    type: trojan
+    server: jp.example.com
+    port: 443
+    password: secret
+    tfo: true # 开启 TCP Fast Open
+proxy-groups:
+  - name: Proxy
+    type: select
+    proxies: [HK, JP, DIRECT]
+rules:
+  - DOMAIN-SUFFIX,corp.example.com,DIRECT # 公司内网
+  - GEOIP,CN,DIRECT
+  - MATCH,Proxy
+`
+
+// newDocumentManager 创建写入了 testDocument 的配置管理器
+func newDocumentManager(t *testing.T) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(testDocument), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(dir)
+}
+
+// readConfigFile 读取配置文件内容
+func readConfigFile(t *testing.T, m *Manager) string {
+	t.Helper()
+	data, err := os.ReadFile(m.GetConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSaveUnchangedKeepsDocument(t *testing.T) {
+	m := newDocumentManager(t)
+	cfg, err := m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readConfigFile(t, m); got != testDocument {
+		t.Errorf("unchanged config was rewritten:\n%s", got)
+	}
+}
+
+// editDocument 对 testDocument 做的修改：删除第一个节点、修改第二个节点、
+// 调整组成员并插入规则
+func editDocument(cfg *Config) error {
+	cfg.Mode = "global"
+	cfg.Proxies = cfg.Proxies[1:]
+	cfg.Proxies[0].Port = 8443
+	cfg.ProxyGroups[0].Proxies = []string{"DIRECT", "JP"}
+	cfg.Rules = append([]string{"DOMAIN,blocked.example.com,REJECT"}, cfg.Rules...)
+	return nil
+}
+
+// checkEditedDocument 检查修改后的文件保留了注释、未知字段和顺序
+func checkEditedDocument(t *testing.T, got string) {
+	t.Helper()
+
+	for _, want := range []string{
+		"# 家里的配置",
+		"port: 7890 # HTTP 端口",
+		"mode: global",
+		"sniffer:\n  enable: true\n  sniff:\n    TLS:\n      ports: [443, 8443]",
+		"nameserver-policy:\n    '+.corp.example.com': 10.0.0.53",
+		"tfo: true # 开启 TCP Fast Open",
+		"port: 8443",
+		"proxies: [DIRECT, JP]",
+		"- DOMAIN-SUFFIX,corp.example.com,DIRECT # 公司内网",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("saved config lost %q:\n%s", want, got)
+		}
+	}
+
+	// 删除的节点连同它的注释和未知字段一起删除，JP 的未知字段不受影响
+	if strings.Contains(got, "hk.example.com") || strings.Contains(got, "# 香港节点") {
+		t.Errorf("removed proxy still present:\n%s", got)
+	}
+	if n := strings.Count(got, "tfo: true"); n != 1 {
+		t.Errorf("tfo appears %d times, want 1:\n%s", n, got)
+	}
+
+	// 列表保持修改后的顺序
+	order := []string{
+		"DOMAIN,blocked.example.com,REJECT",
+		"DOMAIN-SUFFIX,corp.example.com,DIRECT",
+		"GEOIP,CN,DIRECT",
+		"MATCH,Proxy",
+	}
+	last := -1
+	for _, rule := range order {
+		idx := strings.Index(got, rule)
+		if idx <= last {
+			t.Errorf("rule %q out of order:\n%s", rule, got)
+		}
+		last = idx
+	}
+}
+
+func TestSaveMergesEdits(t *testing.T) {
+	m := newDocumentManager(t)
+	cfg, err := m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editDocument(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	checkEditedDocument(t, readConfigFile(t, m))
+
+	// 再次加载后保存不应产生新的改动
+	saved := readConfigFile(t, m)
+	cfg, err = m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := readConfigFile(t, m); got != saved {
+		t.Errorf("second save changed the file:\n%s", got)
+	}
+}
+
+func TestUpdateMergesEdits(t *testing.T) {
+	m := newDocumentManager(t)
+	if _, err := m.Update(editDocument); err != nil {
+		t.Fatal(err)
+	}
+	checkEditedDocument(t, readConfigFile(t, m))
+}
+
+func TestMergeSequenceMatchesByName(t *testing.T) {
+	// 节点顺序调整后，未知字段跟随同名节点移动
+	m := newDocumentManager(t)
+	_, err := m.Update(func(cfg *Config) error {
+		cfg.Proxies[0], cfg.Proxies[1] = cfg.Proxies[1], cfg.Proxies[0]
+		cfg.Proxies[1].Name = "HK-2"
+		cfg.ProxyGroups[0].Proxies[0] = "HK-2"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := readConfigFile(t, m)
+	jp := strings.Index(got, "- name: JP")
+	hk := strings.Index(got, "- name: HK-2")
+	if jp < 0 || hk < 0 || jp > hk {
+		t.Fatalf("proxies not reordered:\n%s", got)
+	}
+	// JP 按名称匹配，保留原有的注释和 tfo；改名的节点视为新节点
+	if !strings.Contains(got[jp:hk], "tfo: true # 开启 TCP Fast Open") {
+		t.Errorf("JP lost its unknown keys or comments:\n%s", got)
+	}
+	if strings.Contains(got[hk:], "tfo") {
+		t.Errorf("renamed proxy kept fields of the old entry:\n%s", got)
+	}
+}
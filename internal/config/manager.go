@@ -1,18 +1,26 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/clash-fish/clash-fish/pkg/logger"
+	"github.com/clash-fish/clash-fish/pkg/utils"
 	"gopkg.in/yaml.v3"
 )
 
+// BackupCount 配置文件保留的轮转备份数量（config.yaml.bak、config.yaml.bak.1 ...）
+const BackupCount = 3
+
 // Manager 配置管理器
 type Manager struct {
 	configDir  string
 	configPath string
 	config     *Config
+	doc        *document // 已加载的原始文档，保存时保留未知字段和注释
+	overrides  map[string]interface{}
 }
 
 // NewManager 创建配置管理器
@@ -25,6 +33,11 @@ func NewManager(configDir string) *Manager {
 
 // Init 初始化配置目录和文件
 func (m *Manager) Init() error {
+	return m.InitWith(GetDefaultConfig())
+}
+
+// InitWith 初始化配置目录，配置文件不存在时写入指定配置
+func (m *Manager) InitWith(config *Config) error {
 	// 创建配置目录
 	if err := os.MkdirAll(m.configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
@@ -33,7 +46,7 @@ func (m *Manager) Init() error {
 	// 创建子目录
 	dirs := []string{
 		filepath.Join(m.configDir, "logs"),
-		filepath.Join(m.configDir, "profiles"),
+		m.GetProfilesDir(),
 		filepath.Join(m.configDir, "cache"),
 	}
 
@@ -43,9 +56,9 @@ func (m *Manager) Init() error {
 		}
 	}
 
-	// 如果配置文件不存在，创建默认配置
+	// 如果配置文件不存在，写入配置
 	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
-		if err := m.CreateDefault(); err != nil {
+		if err := m.Save(config); err != nil {
 			return fmt.Errorf("failed to create default config: %w", err)
 		}
 	}
@@ -59,99 +72,310 @@ func (m *Manager) CreateDefault() error {
 	return m.Save(config)
 }
 
-// Load 加载配置文件
+// Load 加载配置文件。旧版本的配置只在内存中升级，不写回磁盘，
+// 由 config migrate 持久化（或随之后的 Save 一并写入）
 func (m *Manager) Load() (*Config, error) {
 	data, err := os.ReadFile(m.configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	result, err := Migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config file: %w", err)
+	}
+	if result.Changed() {
+		logger.Info().Int("from", result.From).Int("to", result.To).Int("changes", len(result.Changes)).
+			Msg("Configuration migrated in memory, run 'clash-fish config migrate' to save it")
+	}
+
+	doc, config, err := parseDocument(result.Data)
+	if err != nil {
+		return nil, err
 	}
 
-	m.config = &config
-	return &config, nil
+	m.config = config
+	m.doc = doc
+	return config, nil
 }
 
-// Save 保存配置文件
+// Save 保存配置文件。配置由 Load 加载时只写回修改的字段，
+// 文件中 Config 结构之外的字段和注释保持不变。
 func (m *Manager) Save(config *Config) error {
-	data, err := yaml.Marshal(config)
+	unlock, err := utils.LockFile(m.configPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
 	}
+	defer unlock()
 
-	// 添加配置文件头部注释
-	header := []byte("# Clash-Fish Configuration\n# Auto-generated configuration file\n\n")
-	data = append(header, data...)
+	var data []byte
+	if m.doc != nil {
+		merged, err := m.doc.merge(config)
+		if err != nil {
+			return err
+		}
+		data = merged
+	} else {
+		marshaled, err := yaml.Marshal(config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
 
-	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+		// 添加配置文件头部注释
+		header := []byte("# Clash-Fish Configuration\n# Auto-generated configuration file\n\n")
+		data = append(header, marshaled...)
+
+		if m.doc, _, err = parseDocument(data); err != nil {
+			return err
+		}
+	}
+
+	if err := m.writeConfig(data); err != nil {
+		return err
 	}
 
 	m.config = config
 	return nil
 }
 
-// Validate 验证配置文件
-func (m *Manager) Validate(config *Config) error {
-	// 验证必需字段
-	if config.Port <= 0 || config.Port > 65535 {
-		return fmt.Errorf("invalid port: %d", config.Port)
+// Migrate 将配置文件升级到当前版本（不需要修改的旧配置只更新版本号），
+// 写入前轮转备份旧内容；dryRun 时只返回结果不写入
+func (m *Manager) Migrate(dryRun bool) (*MigrationResult, error) {
+	unlock, err := utils.LockFile(m.configPath)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 
-	if config.SocksPort <= 0 || config.SocksPort > 65535 {
-		return fmt.Errorf("invalid socks-port: %d", config.SocksPort)
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// 验证模式
-	validModes := map[string]bool{
-		"rule":   true,
-		"global": true,
-		"direct": true,
+	result, err := Migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config file: %w", err)
 	}
-	if !validModes[config.Mode] {
-		return fmt.Errorf("invalid mode: %s (must be rule/global/direct)", config.Mode)
+	if dryRun || result.From == CurrentVersion {
+		return result, nil
+	}
+	// 没有需要修改的内容时只更新版本号
+	if !result.Changed() {
+		if result.Data, err = StampVersion(data); err != nil {
+			return nil, err
+		}
+		result.To = CurrentVersion
 	}
 
-	// 验证日志级别
-	validLogLevels := map[string]bool{
-		"info":    true,
-		"warning": true,
-		"error":   true,
-		"debug":   true,
-		"silent":  true,
+	if err := m.writeConfig(result.Data); err != nil {
+		return nil, err
 	}
-	if !validLogLevels[config.LogLevel] {
-		return fmt.Errorf("invalid log-level: %s", config.LogLevel)
+	return result, nil
+}
+
+// BuildEffective 生成 mihomo 实际加载的配置：主配置叠加本地覆盖层和设置覆盖，并解析密钥引用
+func (m *Manager) BuildEffective() ([]byte, error) {
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// 验证 TUN 配置
-	if config.TUN.Enable {
-		validStacks := map[string]bool{
-			"system": true,
-			"gvisor": true,
-		}
-		if !validStacks[config.TUN.Stack] {
-			return fmt.Errorf("invalid tun.stack: %s (must be system/gvisor)", config.TUN.Stack)
+	if data, err = m.Compose(data); err != nil {
+		return nil, err
+	}
+
+	// 密钥引用只在这里解析，实际值不会写回磁盘
+	return ResolveSecrets(data, NewSecretStore(m.configDir))
+}
+
+// Compose 在配置内容上依次应用版本迁移、本地覆盖层和设置覆盖，不解析密钥引用
+func (m *Manager) Compose(data []byte) ([]byte, error) {
+	// 未迁移的旧配置在内存中升级后再交给 mihomo
+	result, err := Migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config file: %w", err)
+	}
+	data = result.Data
+
+	mixin, err := readMixin(m.GetMixinPath())
+	if err != nil {
+		return nil, err
+	}
+	if mixin != nil {
+		if data, err = ApplyMixin(data, mixin); err != nil {
+			return nil, err
 		}
 	}
 
-	// 验证 DNS 配置
-	if config.DNS.Enable {
-		validModes := map[string]bool{
-			"fake-ip":    true,
-			"redir-host": true,
+	// 环境变量和命令行标志的覆盖优先级最高
+	if len(m.overrides) > 0 {
+		overlay, err := yaml.Marshal(m.overrides)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal overrides: %w", err)
+		}
+		if data, err = ApplyMixin(data, overlay); err != nil {
+			return nil, err
 		}
-		if !validModes[config.DNS.EnhancedMode] {
-			return fmt.Errorf("invalid dns.enhanced-mode: %s", config.DNS.EnhancedMode)
+	}
+	return data, nil
+}
+
+// ValidateEffective 校验配置内容（如待应用的 profile）叠加覆盖层后的实际配置，
+// 有错误时返回 *ValidationError
+func (m *Manager) ValidateEffective(data []byte) error {
+	composed, err := m.Compose(data)
+	if err != nil {
+		return err
+	}
+	issues, err := ValidateData(composed)
+	if err != nil {
+		return err
+	}
+	if HasErrors(issues) {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// ValidateReferences 检查配置内容叠加覆盖层后的引用关系，不要求完整的运行设置
+func (m *Manager) ValidateReferences(data []byte) error {
+	composed, err := m.Compose(data)
+	if err != nil {
+		return err
+	}
+	return ValidateReferences(composed)
+}
+
+// SetOverrides 设置生成实际配置时最后叠加的字段（来自环境变量或命令行标志）
+func (m *Manager) SetOverrides(overrides map[string]interface{}) {
+	m.overrides = overrides
+}
+
+// SaveRaw 原样原子写入配置内容（用于应用 profile），写入前校验能否解析
+func (m *Manager) SaveRaw(data []byte) error {
+	unlock, err := utils.LockFile(m.configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return m.saveRaw(data)
+}
+
+// saveRaw 写入配置内容并更新已加载的文档，调用方需持有锁
+func (m *Manager) saveRaw(data []byte) error {
+	doc, config, err := parseDocument(data)
+	if err != nil {
+		return err
+	}
+
+	if err := m.writeConfig(data); err != nil {
+		return err
+	}
+
+	m.config = config
+	m.doc = doc
+	return nil
+}
+
+// writeConfig 轮转备份旧内容后原子写入配置文件，调用方需持有锁。
+// 配置中包含代理密码，文件权限为仅所有者可读写。
+func (m *Manager) writeConfig(data []byte) error {
+	// 内容未变化时不轮转，避免挤掉有用的备份
+	if current, err := os.ReadFile(m.configPath); err != nil || !bytes.Equal(current, data) {
+		if err := utils.BackupFile(m.configPath, BackupCount); err != nil {
+			return err
 		}
 	}
 
+	if err := utils.WriteFileAtomic(m.configPath, data, utils.PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
 	return nil
 }
 
+// Update 在文件锁内完成加载、修改和保存：update 修改 Config 结构，
+// 修改按文档合并方式写回（保留未知字段和注释），通过完整校验后才原子写入。
+// 校验失败时返回 *ValidationError，文件保持不变；成功时返回新内容的所有问题（警告）。
+func (m *Manager) Update(update func(config *Config) error) ([]Issue, error) {
+	unlock, err := utils.LockFile(m.configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	doc, config, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update(config); err != nil {
+		return nil, err
+	}
+	next, err := doc.merge(config)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := ValidateData(next)
+	if err != nil {
+		return nil, err
+	}
+	if HasErrors(issues) {
+		return nil, &ValidationError{Issues: issues}
+	}
+
+	if err := m.writeConfig(next); err != nil {
+		return nil, err
+	}
+	m.config = config
+	m.doc = doc
+	return issues, nil
+}
+
+// Edit 修改配置文件内容：edit 返回的新内容通过完整校验后才原子写入。
+// 新内容存在任何错误时返回 *ValidationError，文件保持不变；成功时返回新内容的所有问题。
+// 读取到写入期间持有文件锁，并发的修改不会互相覆盖。
+func (m *Manager) Edit(edit func(data []byte) ([]byte, error)) ([]Issue, error) {
+	unlock, err := utils.LockFile(m.configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	next, err := edit(data)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := ValidateData(next)
+	if err != nil {
+		return nil, err
+	}
+	if HasErrors(issues) {
+		return nil, &ValidationError{Issues: issues}
+	}
+
+	if err := m.saveRaw(next); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// Validate 验证配置文件
+func (m *Manager) Validate(config *Config) error {
+	return Validate(config)
+}
+
 // GetConfigPath 获取配置文件路径
 func (m *Manager) GetConfigPath() string {
 	return m.configPath
@@ -162,6 +386,16 @@ func (m *Manager) GetConfigDir() string {
 	return m.configDir
 }
 
+// GetProfilesDir 获取 profiles 目录路径
+func (m *Manager) GetProfilesDir() string {
+	return filepath.Join(m.configDir, "profiles")
+}
+
+// GetMixinPath 获取本地覆盖层文件路径
+func (m *Manager) GetMixinPath() string {
+	return filepath.Join(m.configDir, MixinFileName)
+}
+
 // Exists 检查配置文件是否存在
 func (m *Manager) Exists() bool {
 	_, err := os.Stat(m.configPath)
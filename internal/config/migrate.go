@@ -0,0 +1,321 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VersionKey 配置格式版本字段
+const VersionKey = "clash-fish-version"
+
+// CurrentVersion 当前配置格式版本，新增迁移时递增
+const CurrentVersion = 3
+
+// Migration 将配置从 Version-1 升级到 Version 的一步迁移。
+// Apply 直接修改 YAML 文档（保留注释和未知字段），返回所做修改的说明。
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(root *yaml.Node) []string
+}
+
+// migrations 迁移注册表，按版本顺序执行
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "rename legacy Clash keys",
+		Apply:       migrateLegacyKeys,
+	},
+	{
+		Version:     2,
+		Description: "move experimental settings into their own sections",
+		Apply:       migrateExperimental,
+	},
+	{
+		Version:     3,
+		Description: "normalize proxy group types",
+		Apply:       migrateGroupTypes,
+	},
+}
+
+// MigrationResult 迁移结果
+type MigrationResult struct {
+	From    int
+	To      int
+	Changes []string
+	Data    []byte
+}
+
+// Changed 检查迁移是否修改了配置内容
+func (r *MigrationResult) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+// Migrate 将配置内容升级到当前版本。没有需要修改的内容时原样返回，不更新版本号；
+// 版本高于当前版本（由更新的 clash-fish 写入）时返回错误。
+func Migrate(data []byte) (*MigrationResult, error) {
+	doc, _, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	root := doc.content()
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file is not a mapping")
+	}
+
+	version, err := configVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &MigrationResult{From: version, To: version, Data: data}
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("config was written by a newer clash-fish (%s %d, this build supports %d)", VersionKey, version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return result, nil
+	}
+
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+		for _, change := range m.Apply(root) {
+			result.Changes = append(result.Changes, fmt.Sprintf("v%d %s: %s", m.Version, m.Description, change))
+		}
+	}
+	if !result.Changed() {
+		return result, nil
+	}
+	setVersion(root, CurrentVersion)
+	result.To = CurrentVersion
+
+	if result.Data, err = doc.bytes(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StampVersion 将配置格式版本更新为当前版本，不做其他修改；
+// 用于 config migrate 标记不需要迁移的旧配置
+func StampVersion(data []byte) ([]byte, error) {
+	doc, _, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	root := doc.content()
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file is not a mapping")
+	}
+	setVersion(root, CurrentVersion)
+	return doc.bytes()
+}
+
+// configVersion 读取配置格式版本，没有该字段时为 0
+func configVersion(root *yaml.Node) (int, error) {
+	node := mappingGet(root, VersionKey)
+	if node == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", VersionKey, node.Value)
+	}
+	return version, nil
+}
+
+// setVersion 写入配置格式版本，新增时放在文件开头
+func setVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if idx := mappingIndex(root, VersionKey); idx >= 0 {
+		replaceNode(root.Content[idx+1], value)
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: VersionKey}
+	// 文件头部注释保留在最前面
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// renameKey 重命名映射中的键，返回修改说明，没有旧键时返回空。
+// 新键已存在时把旧键的内容合并进去；两者类型不同无法合并时保留旧键并说明冲突
+func renameKey(node *yaml.Node, from, to string) string {
+	idx := mappingIndex(node, from)
+	if idx < 0 {
+		return ""
+	}
+	dst := mappingGet(node, to)
+	if dst == nil {
+		node.Content[idx].Value = to
+		return fmt.Sprintf("renamed %q to %s", from, to)
+	}
+
+	src := node.Content[idx+1]
+	merged, ok := mergeLegacy(dst, src)
+	if !ok {
+		return fmt.Sprintf("kept %q: %s already exists with a different type, merge them by hand", from, to)
+	}
+	node.Content = append(node.Content[:idx], node.Content[idx+2:]...)
+	return fmt.Sprintf("merged %d item(s) from %q into %s", merged, from, to)
+}
+
+// mergeLegacy 将旧键的内容合并到新键：列表追加新键中没有的项（同名代理、代理组以新键为准），
+// 映射补充新键中没有的字段。返回合并的项数，类型不同时返回 false
+func mergeLegacy(dst, src *yaml.Node) (int, bool) {
+	if src.Kind == yaml.ScalarNode && src.Tag == "!!null" {
+		return 0, true
+	}
+	if dst.Kind != src.Kind {
+		return 0, false
+	}
+
+	merged := 0
+	switch dst.Kind {
+	case yaml.SequenceNode:
+		for _, item := range src.Content {
+			if !containsItem(dst, item) {
+				dst.Content = append(dst.Content, item)
+				merged++
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if mappingIndex(dst, src.Content[i].Value) < 0 {
+				dst.Content = append(dst.Content, src.Content[i], src.Content[i+1])
+				merged++
+			}
+		}
+	default:
+		return 0, false
+	}
+	return merged, true
+}
+
+// containsItem 检查列表中是否已有相同的项，带 name 的映射按名称比较
+func containsItem(list, item *yaml.Node) bool {
+	name := nodeName(item)
+	for _, existing := range list.Content {
+		if name != "" && nodeName(existing) == name {
+			return true
+		}
+		if nodeEqual(existing, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureMapping 获取映射中的子映射，不存在时创建
+func ensureMapping(node *yaml.Node, key string) *yaml.Node {
+	if child := mappingGet(node, key); child != nil && child.Kind == yaml.MappingNode {
+		return child
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if idx := mappingIndex(node, key); idx >= 0 {
+		node.Content[idx+1] = child
+	} else {
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+	}
+	return child
+}
+
+// migrateLegacyKeys v1：原版 Clash 的旧键名改为 mihomo 键名
+func migrateLegacyKeys(root *yaml.Node) []string {
+	var changes []string
+	for _, r := range []struct{ from, to string }{
+		{"Proxy", "proxies"},
+		{"Proxy Group", "proxy-groups"},
+		{"Rule", "rules"},
+		{"proxy-provider", "proxy-providers"},
+		{"rule-provider", "rule-providers"},
+	} {
+		if change := renameKey(root, r.from, r.to); change != "" {
+			changes = append(changes, change)
+		}
+	}
+
+	// enable-process 已被 find-process-mode 取代
+	if idx := mappingIndex(root, "enable-process"); idx >= 0 {
+		mode := "off"
+		if root.Content[idx+1].Value == "true" {
+			mode = "always"
+		}
+		root.Content = append(root.Content[:idx], root.Content[idx+2:]...)
+		if mappingIndex(root, "find-process-mode") < 0 {
+			root.Content = append(root.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "find-process-mode"},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: mode})
+		}
+		changes = append(changes, "replaced enable-process with find-process-mode: "+mode)
+	}
+	return changes
+}
+
+// migrateExperimental v2：experimental 中已有独立配置段的设置移到对应位置
+func migrateExperimental(root *yaml.Node) []string {
+	experimental := mappingGet(root, "experimental")
+	if experimental == nil || experimental.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var changes []string
+	if idx := mappingIndex(experimental, "sniff-tls-sni"); idx >= 0 {
+		enabled := experimental.Content[idx+1].Value == "true"
+		experimental.Content = append(experimental.Content[:idx], experimental.Content[idx+2:]...)
+		if enabled {
+			sniffer := ensureMapping(root, "sniffer")
+			if mappingIndex(sniffer, "enable") < 0 {
+				sniffer.Content = append(sniffer.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "enable"},
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+			}
+		}
+		changes = append(changes, "moved experimental.sniff-tls-sni to sniffer.enable")
+	}
+
+	if len(experimental.Content) == 0 {
+		idx := mappingIndex(root, "experimental")
+		root.Content = append(root.Content[:idx], root.Content[idx+2:]...)
+		changes = append(changes, "removed empty experimental section")
+	}
+	return changes
+}
+
+// legacyGroupTypes 旧写法的代理组类型
+var legacyGroupTypes = map[string]string{
+	"urltest":      "url-test",
+	"url_test":     "url-test",
+	"loadbalance":  "load-balance",
+	"load_balance": "load-balance",
+}
+
+// migrateGroupTypes v3：代理组类型统一为 mihomo 的小写连字符写法
+func migrateGroupTypes(root *yaml.Node) []string {
+	groups := mappingGet(root, "proxy-groups")
+	if groups == nil || groups.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var changes []string
+	for _, group := range groups.Content {
+		typ := mappingGet(group, "type")
+		if typ == nil || typ.Kind != yaml.ScalarNode {
+			continue
+		}
+		normalized := strings.ToLower(typ.Value)
+		if legacy, ok := legacyGroupTypes[normalized]; ok {
+			normalized = legacy
+		}
+		if normalized != typ.Value {
+			changes = append(changes, fmt.Sprintf("group %q type %s -> %s", nodeName(group), typ.Value, normalized))
+			typ.Value = normalized
+		}
+	}
+	return changes
+}
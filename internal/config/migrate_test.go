@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrateUnchanged(t *testing.T) {
+	data := []byte("# comment\nport: 7890\nproxy-groups:\n  - {name: PROXY, type: select, proxies: [DIRECT]}\n")
+	result, err := Migrate(data)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if result.Changed() || result.From != 0 || result.To != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if !bytes.Equal(result.Data, data) {
+		t.Fatalf("unchanged config was re-encoded:\n%s", result.Data)
+	}
+}
+
+func TestMigrateLegacyKeys(t *testing.T) {
+	data := []byte(`clash-fish-version: 0
+Proxy:
+  - {name: HK, type: ss, server: old.example.com, port: 443}
+  - {name: JP, type: ss, server: jp.example.com, port: 443}
+proxies:
+  - {name: HK, type: ss, server: hk.example.com, port: 443}
+Rule:
+  - DOMAIN-SUFFIX,a.example,PROXY
+  - MATCH,DIRECT
+rules:
+  - MATCH,DIRECT
+proxy-provider: [not, a, mapping]
+proxy-providers:
+  sub: {type: http, url: "https://example.com/sub.yaml"}
+rule-provider:
+  ads: {type: http, url: "https://example.com/ads.yaml"}
+`)
+	result, err := Migrate(data)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if !result.Changed() || result.To != CurrentVersion {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	wantChanges := []string{
+		`v1 rename legacy Clash keys: merged 1 item(s) from "Proxy" into proxies`,
+		`v1 rename legacy Clash keys: merged 1 item(s) from "Rule" into rules`,
+		`v1 rename legacy Clash keys: kept "proxy-provider": proxy-providers already exists with a different type, merge them by hand`,
+		`v1 rename legacy Clash keys: renamed "rule-provider" to rule-providers`,
+	}
+	if !reflect.DeepEqual(result.Changes, wantChanges) {
+		t.Fatalf("changes =\n%s\nwant\n%s", strings.Join(result.Changes, "\n"), strings.Join(wantChanges, "\n"))
+	}
+
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(result.Data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg["Proxy"]; ok {
+		t.Error("merged legacy key was kept")
+	}
+	if _, ok := cfg["proxy-provider"]; !ok {
+		t.Error("conflicting legacy key was dropped")
+	}
+	proxies := cfg["proxies"].([]interface{})
+	if len(proxies) != 2 || proxies[0].(map[string]interface{})["server"] != "hk.example.com" {
+		t.Errorf("proxies = %v", proxies)
+	}
+	if got := cfg["rules"]; !reflect.DeepEqual(got, []interface{}{"MATCH,DIRECT", "DOMAIN-SUFFIX,a.example,PROXY"}) {
+		t.Errorf("rules = %v", got)
+	}
+	if cfg[VersionKey] != CurrentVersion {
+		t.Errorf("version = %v", cfg[VersionKey])
+	}
+}
+
+func TestManagerLoadDoesNotWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := []byte("port: 7890\nproxy-groups:\n  - {name: PROXY, type: urltest, proxies: [DIRECT]}\n")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := NewManager(dir)
+	cfg, err := mgr.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ProxyGroups[0].Type != "url-test" || cfg.Version != CurrentVersion {
+		t.Errorf("config not migrated in memory: %+v", cfg.ProxyGroups[0])
+	}
+
+	onDisk, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(onDisk, data) {
+		t.Fatalf("Load wrote the config file:\n%s", onDisk)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Fatalf("Load created a backup: %v", err)
+	}
+
+	// dry run 只报告，显式迁移才写入
+	result, err := mgr.Migrate(true)
+	if err != nil || !result.Changed() {
+		t.Fatalf("dry run = %+v, %v", result, err)
+	}
+	if onDisk, _ = os.ReadFile(path); !bytes.Equal(onDisk, data) {
+		t.Fatal("dry run wrote the config file")
+	}
+	if _, err := mgr.Migrate(false); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if onDisk, _ = os.ReadFile(path); !bytes.Contains(onDisk, []byte("url-test")) {
+		t.Fatalf("migration not written:\n%s", onDisk)
+	}
+}
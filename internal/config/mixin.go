@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MixinFileName 本地覆盖层文件名，位于配置目录下
+const MixinFileName = "mixin.yaml"
+
+// Mixin 中的特殊键，其余键按深度合并处理
+const (
+	mixinPrependRules = "prepend-rules"
+	mixinAppendRules  = "append-rules"
+	mixinProxies      = "proxies"
+	mixinProxyGroups  = "proxy-groups"
+)
+
+// ApplyMixin 将覆盖层合并到配置内容上：
+//   - prepend-rules / append-rules：插入到规则列表开头 / 末尾
+//   - proxies / proxy-groups：按 name 替换同名项，不存在则追加
+//   - 其他键：映射递归合并，标量和列表直接替换
+func ApplyMixin(base, mixin []byte) ([]byte, error) {
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(base, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if cfg == nil {
+		cfg = make(map[string]interface{})
+	}
+
+	var overlay map[string]interface{}
+	if err := yaml.Unmarshal(mixin, &overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse mixin: %w", err)
+	}
+
+	for key, value := range overlay {
+		switch key {
+		case mixinPrependRules:
+			rules, err := toList(value, key)
+			if err != nil {
+				return nil, err
+			}
+			cfg["rules"] = append(rules, listOf(cfg["rules"])...)
+		case mixinAppendRules:
+			rules, err := toList(value, key)
+			if err != nil {
+				return nil, err
+			}
+			cfg["rules"] = append(listOf(cfg["rules"]), rules...)
+		case mixinProxies, mixinProxyGroups:
+			items, err := toList(value, key)
+			if err != nil {
+				return nil, err
+			}
+			merged, err := mergeByName(listOf(cfg[key]), items, key)
+			if err != nil {
+				return nil, err
+			}
+			cfg[key] = merged
+		default:
+			cfg[key] = deepMerge(cfg[key], value)
+		}
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// mergeByName 按 name 字段替换或追加列表项
+func mergeByName(base, items []interface{}, key string) ([]interface{}, error) {
+	result := append([]interface{}(nil), base...)
+
+	for _, item := range items {
+		name := nameOf(item)
+		if name == "" {
+			return nil, fmt.Errorf("mixin %s entry without name", key)
+		}
+
+		replaced := false
+		for i, existing := range result {
+			if nameOf(existing) == name {
+				result[i] = item
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// deepMerge 递归合并映射，非映射值由 overlay 直接替换
+func deepMerge(base, overlay interface{}) interface{} {
+	baseMap, ok1 := base.(map[string]interface{})
+	overlayMap, ok2 := overlay.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return overlay
+	}
+
+	for key, value := range overlayMap {
+		baseMap[key] = deepMerge(baseMap[key], value)
+	}
+	return baseMap
+}
+
+// toList 将 mixin 的值转换为列表
+func toList(value interface{}, key string) ([]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mixin %s must be a list", key)
+	}
+	return list, nil
+}
+
+// listOf 读取配置中的列表，类型不符时返回空
+func listOf(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+// nameOf 读取列表项的 name 字段
+func nameOf(item interface{}) string {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	name, _ := m["name"].(string)
+	return name
+}
+
+// readMixin 读取覆盖层文件，不存在时返回 nil
+func readMixin(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mixin file: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,455 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pathStep 点分路径中的一步：映射键或列表下标
+type pathStep struct {
+	key     string
+	index   int  // 列表下标，负数从末尾计数
+	isIndex bool // 是否为列表下标
+	append  bool // [+] 在列表末尾追加
+}
+
+// String 路径步骤的文本形式
+func (s pathStep) String() string {
+	switch {
+	case !s.isIndex:
+		return s.key
+	case s.append:
+		return "[+]"
+	default:
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+}
+
+// formatPath 将路径步骤格式化为点分路径
+func formatPath(steps []pathStep) string {
+	var b strings.Builder
+	for i, s := range steps {
+		if i > 0 && !s.isIndex {
+			b.WriteByte('.')
+		}
+		b.WriteString(s.String())
+	}
+	return b.String()
+}
+
+// parsePath 解析点分路径，如 tun.stack、dns.nameserver[0]、dns.nameserver[+]、proxies[-1].port
+func parsePath(path string) ([]pathStep, error) {
+	var steps []pathStep
+	rest := strings.TrimSpace(path)
+	if rest == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	for rest != "" {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("invalid path %q: missing key", path)
+		}
+		steps = append(steps, pathStep{key: rest[:end]})
+		rest = rest[end:]
+
+		for strings.HasPrefix(rest, "[") {
+			closing := strings.Index(rest, "]")
+			if closing < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed [", path)
+			}
+			inner := strings.TrimSpace(rest[1:closing])
+			rest = rest[closing+1:]
+
+			if inner == "+" {
+				steps = append(steps, pathStep{isIndex: true, append: true})
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: index %q is not a number or +", path, inner)
+			}
+			steps = append(steps, pathStep{isIndex: true, index: n})
+		}
+
+		if rest != "" {
+			if rest[0] != '.' || len(rest) == 1 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			rest = rest[1:]
+		}
+	}
+	return steps, nil
+}
+
+// schemaType 按路径查找 Config 结构中对应字段的类型。
+// 路径超出已知结构时返回 ok=false；进入 map[string]interface{} 等自由结构后返回 nil 类型（不检查）。
+func schemaType(steps []pathStep) (reflect.Type, bool) {
+	typ := reflect.TypeOf(Config{})
+	for _, step := range steps {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		switch typ.Kind() {
+		case reflect.Interface:
+			return nil, true
+		case reflect.Map:
+			if step.isIndex {
+				return nil, false
+			}
+			typ = typ.Elem()
+		case reflect.Slice:
+			if !step.isIndex {
+				return nil, false
+			}
+			typ = typ.Elem()
+		case reflect.Struct:
+			if step.isIndex {
+				return nil, false
+			}
+			field, ok := structField(typ, step.key)
+			if !ok {
+				return nil, false
+			}
+			typ = field.Type
+		default:
+			return nil, false
+		}
+	}
+	return typ, true
+}
+
+// structField 按 yaml 标签查找结构体字段
+func structField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// typeName 字段类型的描述，用于错误信息
+func typeName(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "a boolean (true or false)"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "an integer"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice:
+		return "a list, e.g. [a, b]"
+	case reflect.Map, reflect.Struct, reflect.Ptr:
+		return "a mapping, e.g. {key: value}"
+	}
+	return typ.String()
+}
+
+// parseValue 将命令行上的值解析为 YAML 节点，并按字段类型检查。
+// 字符串字段按原样保存，其余按 YAML 语法解析（如 true、7890、[a, b]）。
+func parseValue(raw string, typ reflect.Type) (*yaml.Node, error) {
+	if typ != nil && typ.Kind() == reflect.String {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw}, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, fmt.Errorf("invalid value %q: %w", raw, err)
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	if len(doc.Content) > 0 {
+		node = doc.Content[0]
+	}
+
+	// 命令行上的 [a, b]、{k: v} 按文件中常用的块格式写入
+	clearFlowStyle(node)
+	if typ == nil {
+		return node, nil
+	}
+
+	value := reflect.New(typ)
+	if err := node.Decode(value.Interface()); err != nil {
+		return nil, fmt.Errorf("invalid value %q: expected %s", raw, typeName(typ))
+	}
+
+	// 标量按字段类型重新编码，统一写法（如 yes -> true）；
+	// 映射和列表保留原样，以免丢失结构体之外的字段
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Int32:
+		return encodeNode(value.Elem().Interface())
+	}
+	return node, nil
+}
+
+// clearFlowStyle 递归清除映射和列表的流式格式
+func clearFlowStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style &^= yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		clearFlowStyle(child)
+	}
+}
+
+// GetPath 按点分路径读取配置字段的节点
+func GetPath(data []byte, path string) (*yaml.Node, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, _, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	node := doc.content()
+	for i, step := range steps {
+		child, err := childNode(node, step, steps[:i])
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, fmt.Errorf("%s is not set", formatPath(steps[:i+1]))
+		}
+		node = child
+	}
+	return node, nil
+}
+
+// SetPath 按点分路径设置配置字段，返回新的文件内容。
+// 值按 Config 结构中的字段类型检查；不在结构中的字段需要 allowUnknown。
+// 缺少的上级映射会自动创建，[+] 在列表末尾追加。
+func SetPath(data []byte, path, value string, allowUnknown bool) ([]byte, error) {
+	steps, valueNode, err := parseAssignment(path, value, allowUnknown)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, _, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := setNode(doc.content(), steps, valueNode); err != nil {
+		return nil, err
+	}
+	return doc.bytes()
+}
+
+// UnsetPath 按点分路径删除配置字段或列表元素，返回新的文件内容
+func UnsetPath(data []byte, path string) ([]byte, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, _, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := unsetNode(doc.content(), steps, path); err != nil {
+		return nil, err
+	}
+	return doc.bytes()
+}
+
+// IsKnownPath 检查点分路径是否对应 Config 结构中的字段
+func IsKnownPath(path string) bool {
+	steps, err := parsePath(path)
+	if err != nil {
+		return false
+	}
+	_, known := schemaType(steps)
+	return known
+}
+
+// SetConfigPath 按点分路径修改 Config 结构中的字段，只支持结构中已知的字段
+func SetConfigPath(config *Config, path, value string) error {
+	steps, valueNode, err := parseAssignment(path, value, false)
+	if err != nil {
+		return err
+	}
+	return editConfigNode(config, func(root *yaml.Node) error {
+		return setNode(root, steps, valueNode)
+	})
+}
+
+// UnsetConfigPath 按点分路径清除 Config 结构中的字段或删除列表元素，
+// 没有 omitempty 的字段恢复为零值
+func UnsetConfigPath(config *Config, path string) error {
+	steps, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	if _, known := schemaType(steps); !known {
+		return fmt.Errorf("%s is not a known clash-fish setting", path)
+	}
+	return editConfigNode(config, func(root *yaml.Node) error {
+		return unsetNode(root, steps, path)
+	})
+}
+
+// editConfigNode 将 Config 编码为 YAML 节点，修改后解码回结构体
+func editConfigNode(config *Config, edit func(root *yaml.Node) error) error {
+	root, err := encodeNode(config)
+	if err != nil {
+		return err
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+
+	var next Config
+	if err := root.Decode(&next); err != nil {
+		return fmt.Errorf("failed to apply change: %w", err)
+	}
+	*config = next
+	return nil
+}
+
+// parseAssignment 解析路径并按字段类型解析值
+func parseAssignment(path, value string, allowUnknown bool) ([]pathStep, *yaml.Node, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	typ, known := schemaType(steps)
+	if !known && !allowUnknown {
+		return nil, nil, fmt.Errorf("%s is not a known clash-fish setting (use --force to set it anyway)", path)
+	}
+	valueNode, err := parseValue(value, typ)
+	if err != nil {
+		return nil, nil, err
+	}
+	return steps, valueNode, nil
+}
+
+// setNode 在映射节点 root 中按路径设置值
+func setNode(root *yaml.Node, steps []pathStep, valueNode *yaml.Node) error {
+	node := root
+	for i, step := range steps {
+		last := i == len(steps)-1
+
+		if step.append {
+			if node.Kind != yaml.SequenceNode {
+				return fmt.Errorf("%s is not a list", formatPath(steps[:i]))
+			}
+			child := valueNode
+			if !last {
+				child = newContainer(steps[i+1])
+			}
+			node.Content = append(node.Content, child)
+			node = child
+			continue
+		}
+
+		child, err := childNode(node, step, steps[:i])
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case last && child != nil:
+			replaceNode(child, valueNode)
+		case last:
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: step.key}, valueNode)
+		case child == nil:
+			// 映射中缺少的键：按下一步创建映射或列表
+			child = newContainer(steps[i+1])
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: step.key}, child)
+		}
+		node = child
+	}
+	return nil
+}
+
+// unsetNode 在映射节点 root 中按路径删除字段或列表元素
+func unsetNode(root *yaml.Node, steps []pathStep, path string) error {
+	parent := root
+	for i, step := range steps[:len(steps)-1] {
+		child, err := childNode(parent, step, steps[:i])
+		if err != nil {
+			return err
+		}
+		if child == nil {
+			return fmt.Errorf("%s is not set", formatPath(steps[:i+1]))
+		}
+		parent = child
+	}
+
+	last := steps[len(steps)-1]
+	if _, err := childNode(parent, last, steps[:len(steps)-1]); err != nil {
+		return err
+	}
+	if last.isIndex {
+		i, _ := resolveIndex(parent, last.index)
+		parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+		return nil
+	}
+	idx := mappingIndex(parent, last.key)
+	if idx < 0 {
+		return fmt.Errorf("%s is not set", path)
+	}
+	parent.Content = append(parent.Content[:idx], parent.Content[idx+2:]...)
+	return nil
+}
+
+// childNode 获取节点下一步的子节点；映射中不存在的键返回 nil，下标越界返回错误
+func childNode(node *yaml.Node, step pathStep, walked []pathStep) (*yaml.Node, error) {
+	if step.isIndex {
+		if step.append {
+			return nil, fmt.Errorf("[+] can only be used with set")
+		}
+		if node.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s is not a list", formatPath(walked))
+		}
+		i, ok := resolveIndex(node, step.index)
+		if !ok {
+			return nil, fmt.Errorf("index %d out of range, %s has %d item(s)", step.index, formatPath(walked), len(node.Content))
+		}
+		return node.Content[i], nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		if len(walked) == 0 {
+			return nil, fmt.Errorf("config file is not a mapping")
+		}
+		return nil, fmt.Errorf("%s is not a mapping", formatPath(walked))
+	}
+	return mappingGet(node, step.key), nil
+}
+
+// resolveIndex 将下标（可为负数）转换为列表中的位置
+func resolveIndex(node *yaml.Node, index int) (int, bool) {
+	if index < 0 {
+		index += len(node.Content)
+	}
+	return index, index >= 0 && index < len(node.Content)
+}
+
+// newContainer 按下一步的类型创建空映射或空列表
+func newContainer(next pathStep) *yaml.Node {
+	if next.isIndex {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// replaceNode 原地替换节点内容，保留原有注释
+func replaceNode(dst, src *yaml.Node) {
+	head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+}
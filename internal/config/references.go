@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BuiltinTargets mihomo 内置的出站，可直接作为组成员或规则目标
+var BuiltinTargets = map[string]bool{
+	"DIRECT":      true,
+	"REJECT":      true,
+	"REJECT-DROP": true,
+	"PASS":        true,
+	"COMPATIBLE":  true,
+	"GLOBAL":      true,
+}
+
+// healthCheckGroups 需要健康检查地址和间隔的代理组类型
+var healthCheckGroups = map[string]bool{
+	"url-test":     true,
+	"fallback":     true,
+	"load-balance": true,
+}
+
+// RuleTarget 解析规则的目标（代理、代理组或内置出站）。
+// 规则无法解析或为 SUB-RULE（目标是子规则集名称）时返回 false。
+func RuleTarget(rule string) (string, bool) {
+	r, err := ParseRule(rule)
+	if err != nil || r.Type == "SUB-RULE" {
+		return "", false
+	}
+	return r.Target, true
+}
+
+// ValidateReferences 解析配置内容并只检查代理、代理组和规则之间的引用关系，
+// 用于导入或刷新 profile：端口、模式等设置可以在应用时由覆盖层提供
+func ValidateReferences(data []byte) error {
+	root, config, err := decodeData(data)
+	if err != nil {
+		return err
+	}
+
+	c := &checker{}
+	c.checkReferences(config)
+	if issues := attachPositions(root, c.issues); HasErrors(issues) {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// checkReferences 检查代理、代理组和规则之间的引用关系
+func (c *checker) checkReferences(config *Config) {
+	proxies := make(map[string]bool)
+	groups := make(map[string]int)
+
+	// 重复名称：代理之间、代理组之间以及代理与代理组之间
+	for i, p := range config.Proxies {
+		if p.Name == "" {
+			continue
+		}
+		path := fmt.Sprintf("proxies[%d].name", i)
+		switch {
+		case BuiltinTargets[p.Name]:
+			c.errorf(path, "rename the proxy", "proxy name %q is reserved", p.Name)
+		case proxies[p.Name]:
+			c.errorf(path, "proxy names must be unique, rename one of them", "duplicate proxy name %q", p.Name)
+		}
+		proxies[p.Name] = true
+	}
+	for i, g := range config.ProxyGroups {
+		if g.Name == "" {
+			continue
+		}
+		path := fmt.Sprintf("proxy-groups[%d].name", i)
+		_, dup := groups[g.Name]
+		switch {
+		case BuiltinTargets[g.Name]:
+			c.errorf(path, "rename the group", "proxy group name %q is reserved", g.Name)
+		case dup:
+			c.errorf(path, "group names must be unique, rename one of them", "duplicate proxy group name %q", g.Name)
+		case proxies[g.Name]:
+			c.errorf(path, "proxies and groups share one namespace, rename the group", "proxy group %q has the same name as a proxy", g.Name)
+		}
+		if !dup {
+			groups[g.Name] = i
+		}
+	}
+
+	for i, g := range config.ProxyGroups {
+		path := fmt.Sprintf("proxy-groups[%d]", i)
+
+		// 组成员必须是已定义的代理、代理组或内置出站
+		for j, member := range g.Proxies {
+			_, isGroup := groups[member]
+			if !proxies[member] && !isGroup && !BuiltinTargets[member] {
+				c.errorf(fmt.Sprintf("%s.proxies[%d]", path, j), "define the proxy or group, or remove it from the group",
+					"group %q references unknown proxy or group %q", g.Name, member)
+			}
+		}
+
+		if len(g.Proxies) == 0 && len(g.Use) == 0 && !g.IncludeAll && !g.IncludeAllProxies && !g.IncludeAllProviders {
+			c.errorf(path+".proxies", "add at least one proxy, a provider via use, or DIRECT", "group %q has no members", g.Name)
+		}
+
+		if healthCheckGroups[g.Type] {
+			if g.URL == "" {
+				c.warnf(path+".url", "set a health check url, e.g. https://www.gstatic.com/generate_204", "%s group %q has no url", g.Type, g.Name)
+			}
+			if g.Interval <= 0 {
+				c.warnf(path+".interval", "set a health check interval in seconds, e.g. 300", "%s group %q has no interval", g.Type, g.Name)
+			}
+		}
+	}
+
+	c.checkGroupCycles(config, groups)
+
+	// 规则目标必须是已定义的代理、代理组或内置出站
+	for i, rule := range config.Rules {
+		target, ok := RuleTarget(rule)
+		if !ok {
+			continue
+		}
+		if _, isGroup := groups[target]; !isGroup && !proxies[target] && !BuiltinTargets[target] {
+			c.errorf(fmt.Sprintf("rules[%d]", i), "point the rule at an existing group, proxy, DIRECT or REJECT",
+				"rule target %q is not a known proxy or group", target)
+		}
+	}
+}
+
+// checkGroupCycles 检查代理组之间的循环引用，每个环只报告一次
+func (c *checker) checkGroupCycles(config *Config, groups map[string]int) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		idx := groups[name]
+		for j, member := range config.ProxyGroups[idx].Proxies {
+			if _, isGroup := groups[member]; !isGroup {
+				continue
+			}
+			switch state[member] {
+			case visiting:
+				// 从栈中截取环路径
+				start := 0
+				for k, n := range stack {
+					if n == member {
+						start = k
+						break
+					}
+				}
+				cycle := append(append([]string{}, stack[start:]...), member)
+				c.errorf(fmt.Sprintf("proxy-groups[%d].proxies[%d]", idx, j), "remove one of the group references to break the loop",
+					"circular group reference: %s", strings.Join(cycle, " -> "))
+			case unvisited:
+				visit(member)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+
+	for _, g := range config.ProxyGroups {
+		if _, ok := groups[g.Name]; ok && state[g.Name] == unvisited {
+			visit(g.Name)
+		}
+	}
+}
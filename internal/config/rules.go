@@ -0,0 +1,384 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ruleKind 规则类别，决定解析方式和重复判断
+type ruleKind int
+
+const (
+	kindOther ruleKind = iota
+	kindDomain
+	kindLogic
+	kindMatch
+)
+
+// ruleSpec 规则类型定义
+type ruleSpec struct {
+	kind      ruleKind
+	ipParams  bool // 是否允许 no-resolve / src 参数
+	noPayload bool // MATCH 没有匹配内容
+}
+
+// ruleSpecs mihomo 支持的规则类型
+var ruleSpecs = map[string]ruleSpec{
+	"DOMAIN":                {kind: kindDomain},
+	"DOMAIN-SUFFIX":         {kind: kindDomain},
+	"DOMAIN-KEYWORD":        {kind: kindDomain},
+	"DOMAIN-WILDCARD":       {kind: kindDomain},
+	"DOMAIN-REGEX":          {kind: kindDomain},
+	"GEOSITE":               {},
+	"IP-CIDR":               {ipParams: true},
+	"IP-CIDR6":              {ipParams: true},
+	"IP-SUFFIX":             {ipParams: true},
+	"IP-ASN":                {ipParams: true},
+	"GEOIP":                 {ipParams: true},
+	"SRC-GEOIP":             {},
+	"SRC-IP-ASN":            {},
+	"SRC-IP-CIDR":           {},
+	"SRC-IP-SUFFIX":         {},
+	"DST-PORT":              {},
+	"SRC-PORT":              {},
+	"IN-PORT":               {},
+	"IN-TYPE":               {},
+	"IN-USER":               {},
+	"IN-NAME":               {},
+	"PROCESS-PATH":          {},
+	"PROCESS-PATH-REGEX":    {},
+	"PROCESS-PATH-WILDCARD": {},
+	"PROCESS-NAME":          {},
+	"PROCESS-NAME-REGEX":    {},
+	"PROCESS-NAME-WILDCARD": {},
+	"UID":                   {},
+	"NETWORK":               {},
+	"DSCP":                  {},
+	"RULE-SET":              {ipParams: true},
+	"AND":                   {kind: kindLogic},
+	"OR":                    {kind: kindLogic},
+	"NOT":                   {kind: kindLogic},
+	"SUB-RULE":              {kind: kindLogic},
+	"MATCH":                 {kind: kindMatch, noPayload: true},
+}
+
+// Rule 解析后的规则
+type Rule struct {
+	Type    string
+	Payload string
+	Target  string
+	Params  []string
+	Sub     []*Rule // 逻辑规则的子条件
+}
+
+// HasParam 检查规则是否带有指定参数（如 no-resolve）
+func (r *Rule) HasParam(param string) bool {
+	for _, p := range r.Params {
+		if strings.EqualFold(p, param) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRule 解析规则并校验类型和参数格式
+func ParseRule(raw string) (*Rule, error) {
+	ruleType, rest, _ := strings.Cut(strings.TrimSpace(raw), ",")
+	rule := &Rule{Type: strings.ToUpper(strings.TrimSpace(ruleType))}
+
+	spec, ok := ruleSpecs[rule.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule type %q", ruleType)
+	}
+
+	var fields []string
+	switch {
+	case spec.kind == kindLogic:
+		payload, after, err := cutParenthesized(rest)
+		if err != nil {
+			return nil, err
+		}
+		rule.Payload = payload
+		if rule.Sub, err = parseSubRules(rule.Type, payload); err != nil {
+			return nil, err
+		}
+		fields = splitFields(strings.TrimPrefix(strings.TrimSpace(after), ","))
+	case spec.noPayload:
+		fields = splitFields(rest)
+	default:
+		fields = splitFields(rest)
+		if len(fields) == 0 || fields[0] == "" {
+			return nil, fmt.Errorf("%s rule has no value", rule.Type)
+		}
+		rule.Payload, fields = fields[0], fields[1:]
+	}
+
+	if len(fields) == 0 || fields[0] == "" {
+		return nil, fmt.Errorf("%s rule has no target", rule.Type)
+	}
+	rule.Target, rule.Params = fields[0], fields[1:]
+
+	if err := checkPayload(rule.Type, rule.Payload); err != nil {
+		return nil, err
+	}
+
+	for _, param := range rule.Params {
+		switch strings.ToLower(param) {
+		case "no-resolve", "src":
+			if !spec.ipParams {
+				return nil, fmt.Errorf("%q is only valid for IP rules (IP-CIDR, IP-CIDR6, IP-SUFFIX, IP-ASN, GEOIP, RULE-SET), not %s", param, rule.Type)
+			}
+		default:
+			if strings.EqualFold(rule.Target, "no-resolve") {
+				return nil, fmt.Errorf("no-resolve must come after the target")
+			}
+			return nil, fmt.Errorf("unknown rule option %q", param)
+		}
+	}
+	if strings.EqualFold(rule.Target, "no-resolve") || strings.EqualFold(rule.Target, "src") {
+		return nil, fmt.Errorf("%s must come after the target", rule.Target)
+	}
+
+	return rule, nil
+}
+
+// splitFields 按逗号拆分并去除空白
+func splitFields(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	fields := strings.Split(s, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// cutParenthesized 截取开头的括号表达式，返回括号内内容和剩余部分
+func cutParenthesized(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		return "", "", fmt.Errorf("logical rule conditions must be wrapped in parentheses")
+	}
+
+	depth := 0
+	for i, ch := range s {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], s[i+1:], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unbalanced parentheses")
+}
+
+// parseSubRules 解析逻辑规则的子条件，如 (DOMAIN,a.com),(NETWORK,UDP)
+func parseSubRules(ruleType, payload string) ([]*Rule, error) {
+	var subs []*Rule
+	rest := strings.TrimSpace(payload)
+	for rest != "" {
+		inner, after, err := cutParenthesized(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		subType, subPayload, _ := strings.Cut(inner, ",")
+		subType = strings.ToUpper(strings.TrimSpace(subType))
+		spec, ok := ruleSpecs[subType]
+		if !ok || spec.kind == kindMatch {
+			return nil, fmt.Errorf("unknown rule type %q in %s", subType, ruleType)
+		}
+
+		sub := &Rule{Type: subType}
+		if spec.kind == kindLogic {
+			p, _, err := cutParenthesized(subPayload)
+			if err != nil {
+				return nil, err
+			}
+			if sub.Sub, err = parseSubRules(subType, p); err != nil {
+				return nil, err
+			}
+			sub.Payload = p
+		} else {
+			fields := splitFields(subPayload)
+			if len(fields) == 0 || fields[0] == "" {
+				return nil, fmt.Errorf("%s condition in %s has no value", subType, ruleType)
+			}
+			sub.Payload = fields[0]
+			if err := checkPayload(subType, sub.Payload); err != nil {
+				return nil, err
+			}
+		}
+		subs = append(subs, sub)
+
+		rest = strings.TrimPrefix(strings.TrimSpace(after), ",")
+		rest = strings.TrimSpace(rest)
+	}
+
+	switch {
+	case len(subs) == 0:
+		return nil, fmt.Errorf("%s rule has no conditions", ruleType)
+	case (ruleType == "NOT" || ruleType == "SUB-RULE") && len(subs) != 1:
+		return nil, fmt.Errorf("%s rule takes exactly one condition", ruleType)
+	}
+	return subs, nil
+}
+
+// checkPayload 校验规则匹配内容的格式
+func checkPayload(ruleType, payload string) error {
+	switch ruleType {
+	case "IP-CIDR", "IP-CIDR6", "SRC-IP-CIDR":
+		prefix, err := netip.ParsePrefix(payload)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %q", payload)
+		}
+		if ruleType == "IP-CIDR6" && !prefix.Addr().Is6() {
+			return fmt.Errorf("IP-CIDR6 requires an IPv6 CIDR, got %q", payload)
+		}
+	case "DST-PORT", "SRC-PORT", "IN-PORT":
+		for _, part := range strings.Split(payload, "/") {
+			lo, hi, isRange := strings.Cut(part, "-")
+			if !validPort(lo) || (isRange && !validPort(hi)) {
+				return fmt.Errorf("invalid port %q", payload)
+			}
+		}
+	case "DOMAIN", "DOMAIN-SUFFIX", "DOMAIN-KEYWORD":
+		if strings.ContainsAny(payload, " /*") {
+			return fmt.Errorf("invalid domain %q", payload)
+		}
+	case "DOMAIN-REGEX", "PROCESS-NAME-REGEX", "PROCESS-PATH-REGEX":
+		if _, err := regexp.Compile(payload); err != nil {
+			return fmt.Errorf("invalid regex %q: %v", payload, err)
+		}
+	case "NETWORK":
+		if n := strings.ToLower(payload); n != "tcp" && n != "udp" {
+			return fmt.Errorf("NETWORK must be tcp or udp, got %q", payload)
+		}
+	case "IP-ASN", "SRC-IP-ASN", "UID", "DSCP":
+		if _, err := strconv.ParseUint(payload, 10, 32); err != nil {
+			return fmt.Errorf("%s requires a number, got %q", ruleType, payload)
+		}
+	}
+	return nil
+}
+
+// validPort 检查端口号
+func validPort(s string) bool {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	return err == nil && n >= 0 && n <= 65535
+}
+
+// LintRules 检查规则列表：类型与参数格式、MATCH 之后的规则、重复规则以及被前面规则遮蔽的规则
+func LintRules(rules []string) []Issue {
+	c := &checker{}
+
+	type parsed struct {
+		index int
+		rule  *Rule
+	}
+	var seen []parsed
+	matchAt := -1
+	exact := make(map[string]int)
+
+	for i, raw := range rules {
+		path := fmt.Sprintf("rules[%d]", i)
+
+		rule, err := ParseRule(raw)
+		if err != nil {
+			c.errorf(path, "see https://wiki.metacubex.one/config/rules/ for rule syntax", "%s: %v", raw, err)
+			continue
+		}
+
+		if matchAt >= 0 {
+			c.warnf(path, "move the rule above MATCH or delete it", "%s: unreachable, MATCH at rules[%d] catches everything", raw, matchAt)
+			continue
+		}
+
+		key := ruleKey(rule)
+		if first, ok := exact[key]; ok {
+			c.warnf(path, "delete the duplicate", "%s: duplicate of rules[%d]", raw, first)
+			continue
+		}
+		exact[key] = i
+
+		for _, prev := range seen {
+			if !shadows(prev.rule, rule) {
+				continue
+			}
+			if prev.rule.Target == rule.Target {
+				c.warnf(path, "delete the redundant rule", "%s: redundant, rules[%d] (%s) already matches with the same target", raw, prev.index, rules[prev.index])
+			} else {
+				c.warnf(path, "move the rule above rules["+strconv.Itoa(prev.index)+"] or delete it", "%s: never matches, shadowed by rules[%d] (%s)", raw, prev.index, rules[prev.index])
+			}
+			break
+		}
+
+		if rule.Type == "MATCH" {
+			matchAt = i
+		}
+		seen = append(seen, parsed{index: i, rule: rule})
+	}
+
+	return c.issues
+}
+
+// LintData 解析配置内容并检查其中的规则，问题附带 YAML 中的行列号。
+// 只有内容无法解析时才返回 error。
+func LintData(data []byte) ([]Issue, int, error) {
+	root, config, err := decodeData(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return attachPositions(root, LintRules(config.Rules)), len(config.Rules), nil
+}
+
+// ruleKey 规则的规范化形式，用于判断重复
+func ruleKey(r *Rule) string {
+	payload := r.Payload
+	if ruleSpecs[r.Type].kind == kindDomain {
+		payload = strings.ToLower(payload)
+	}
+	return strings.Join(append([]string{r.Type, payload, r.Target}, r.Params...), ",")
+}
+
+// shadows 检查 earlier 能匹配的流量是否包含 later 能匹配的所有流量
+func shadows(earlier, later *Rule) bool {
+	if earlier.Type == "MATCH" {
+		return true
+	}
+
+	// 带 no-resolve 的 IP 规则不匹配需要解析的域名请求，不能遮蔽不带 no-resolve 的规则
+	if earlier.HasParam("no-resolve") && !later.HasParam("no-resolve") && ruleSpecs[later.Type].ipParams {
+		return false
+	}
+	if earlier.HasParam("src") != later.HasParam("src") {
+		return false
+	}
+
+	e, l := strings.ToLower(earlier.Payload), strings.ToLower(later.Payload)
+	switch earlier.Type {
+	case "DOMAIN":
+		return later.Type == "DOMAIN" && e == l
+	case "DOMAIN-SUFFIX":
+		return (later.Type == "DOMAIN" || later.Type == "DOMAIN-SUFFIX") && (l == e || strings.HasSuffix(l, "."+e))
+	case "DOMAIN-KEYWORD":
+		return (later.Type == "DOMAIN" || later.Type == "DOMAIN-SUFFIX" || later.Type == "DOMAIN-KEYWORD") && strings.Contains(l, e)
+	case "IP-CIDR", "IP-CIDR6", "SRC-IP-CIDR":
+		if later.Type != earlier.Type && !(earlier.Type != "SRC-IP-CIDR" && later.Type != "SRC-IP-CIDR") {
+			return false
+		}
+		outer, err1 := netip.ParsePrefix(earlier.Payload)
+		inner, err2 := netip.ParsePrefix(later.Payload)
+		return err1 == nil && err2 == nil && outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr())
+	case "DST-PORT", "SRC-PORT", "IN-PORT", "GEOIP", "GEOSITE", "RULE-SET", "NETWORK", "PROCESS-NAME", "PROCESS-PATH", "IP-ASN":
+		return later.Type == earlier.Type && e == l
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleTarget(t *testing.T) {
+	tests := []struct {
+		rule   string
+		target string
+		ok     bool
+	}{
+		{"DOMAIN-SUFFIX,google.com,Proxy", "Proxy", true},
+		{" ip-cidr , 10.0.0.0/8 , DIRECT , no-resolve", "DIRECT", true},
+		{"MATCH,Final", "Final", true},
+		{"AND,((DOMAIN,a.com),(NETWORK,UDP)),REJECT", "REJECT", true},
+		{"OR,((AND,((DOMAIN,a.com),(DST-PORT,443))),(GEOIP,CN)),Proxy", "Proxy", true},
+		{"SUB-RULE,(NETWORK,tcp),sub-tcp", "", false},
+		{"DOMAIN,a.com", "", false},
+		{"UNKNOWN,a.com,Proxy", "", false},
+		{"AND,((DOMAIN,a.com),REJECT", "", false},
+	}
+
+	for _, tt := range tests {
+		target, ok := RuleTarget(tt.rule)
+		if target != tt.target || ok != tt.ok {
+			t.Errorf("RuleTarget(%q) = %q, %v, want %q, %v", tt.rule, target, ok, tt.target, tt.ok)
+		}
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		path  string // 期望报告问题的规则，为空表示没有问题
+		want  string
+	}{
+		{
+			name:  "after match",
+			rules: []string{"MATCH,Proxy", "DOMAIN,a.com,DIRECT"},
+			path:  "rules[1]",
+			want:  "unreachable, MATCH at rules[0]",
+		},
+		{
+			name:  "duplicate ignores domain case",
+			rules: []string{"DOMAIN,A.com,DIRECT", "domain,a.com,DIRECT"},
+			path:  "rules[1]",
+			want:  "duplicate of rules[0]",
+		},
+		{
+			name:  "suffix shadows subdomain with another target",
+			rules: []string{"DOMAIN-SUFFIX,google.com,Proxy", "DOMAIN,mail.google.com,DIRECT"},
+			path:  "rules[1]",
+			want:  "never matches, shadowed by rules[0]",
+		},
+		{
+			name:  "keyword shadows suffix with the same target",
+			rules: []string{"DOMAIN-KEYWORD,google,Proxy", "DOMAIN-SUFFIX,google.com.hk,Proxy"},
+			path:  "rules[1]",
+			want:  "redundant, rules[0]",
+		},
+		{
+			name:  "wider cidr shadows narrower",
+			rules: []string{"IP-CIDR,10.0.0.0/8,DIRECT", "IP-CIDR,10.1.0.0/16,Proxy"},
+			path:  "rules[1]",
+			want:  "shadowed by rules[0]",
+		},
+		{
+			name:  "no-resolve does not shadow resolving rule",
+			rules: []string{"IP-CIDR,10.0.0.0/8,DIRECT,no-resolve", "IP-CIDR,10.1.0.0/16,Proxy"},
+		},
+		{
+			name:  "narrower cidr first",
+			rules: []string{"IP-CIDR,10.1.0.0/16,Proxy", "IP-CIDR,10.0.0.0/8,DIRECT"},
+		},
+		{
+			name:  "similar suffix is not a subdomain",
+			rules: []string{"DOMAIN-SUFFIX,google.com,Proxy", "DOMAIN-SUFFIX,notgoogle.com,DIRECT"},
+		},
+		{
+			name:  "invalid rule",
+			rules: []string{"DOMAIN,a.com,DIRECT,no-resolve"},
+			path:  "rules[0]",
+			want:  "only valid for IP rules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := LintRules(tt.rules)
+			if tt.path == "" {
+				if len(issues) != 0 {
+					t.Fatalf("unexpected issues: %v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("got %d issues, want 1: %v", len(issues), issues)
+			}
+			if issues[0].Path != tt.path || !strings.Contains(issues[0].Message, tt.want) {
+				t.Errorf("issue = %s, want %s containing %q", issues[0], tt.path, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaURI 生成的 JSON Schema 版本
+const SchemaURI = "http://json-schema.org/draft-07/schema#"
+
+// schemaEnums 取值受限的字段，与 Check 中的校验共用同一份定义
+var schemaEnums = map[string]map[string]bool{
+	"mode":              validModes,
+	"log-level":         validLogLevels,
+	"tun.stack":         validStacks,
+	"dns.enhanced-mode": validEnhancedModes,
+}
+
+// schemaPorts 端口字段
+var schemaPorts = map[string]bool{
+	"port":           true,
+	"socks-port":     true,
+	"proxies[].port": true,
+}
+
+// schemaRequired 列表元素的必填字段，与 Check 中的校验一致
+var schemaRequired = map[string][]string{
+	"proxies[]":      {"name", "type", "server", "port"},
+	"proxy-groups[]": {"name", "type"},
+}
+
+// Schema 由 Config 结构生成 JSON Schema，用于编辑器补全和实时校验。
+// mihomo 的配置项远多于 Config 结构，对象均允许额外字段。
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = SchemaURI
+	schema["title"] = "clash-fish configuration"
+	return schema
+}
+
+// typeSchema 生成类型对应的 schema，path 为点分路径（列表元素记为 []）
+func typeSchema(typ reflect.Type, path string) map[string]interface{} {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	schema := make(map[string]interface{})
+	switch typ.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			properties[name] = typeSchema(field.Type, joinSchemaPath(path, name))
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = true
+		if required, ok := schemaRequired[path]; ok {
+			schema["required"] = required
+		}
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(typ.Elem(), path+"[]")
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(typ.Elem(), path+".*")
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+	}
+
+	if values, ok := schemaEnums[path]; ok {
+		schema["enum"] = sortedKeys(values)
+	}
+	if schemaPorts[path] {
+		schema["minimum"] = 1
+		schema["maximum"] = 65535
+	}
+	if path == "rules[]" {
+		schema["pattern"] = ruleTypePattern()
+	}
+	return schema
+}
+
+// joinSchemaPath 拼接 schema 路径
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ruleTypePattern 规则必须以已知的规则类型开头，与 rule lint 共用类型表
+func ruleTypePattern() string {
+	types := make([]string, 0, len(ruleSpecs))
+	for t := range ruleSpecs {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return "^(" + strings.Join(types, "|") + "),"
+}
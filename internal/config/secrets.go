@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SensitiveKeys 存放凭据的配置字段，这些字段的值可以是密钥引用
+var SensitiveKeys = map[string]bool{
+	"password":       true,
+	"uuid":           true,
+	"obfs-password":  true,
+	"auth":           true,
+	"auth-str":       true,
+	"psk":            true,
+	"private-key":    true,
+	"pre-shared-key": true,
+	"secret":         true,
+	"token":          true,
+}
+
+// 密钥引用前缀
+const (
+	secretRefEnv     = "env:"
+	secretRefFile    = "file:"
+	secretRefKeyring = "keyring:"
+)
+
+// IsSecretRef 检查值是否为密钥引用（env:VAR、file:/path 或 keyring:name）
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, secretRefEnv) ||
+		strings.HasPrefix(value, secretRefFile) ||
+		strings.HasPrefix(value, secretRefKeyring)
+}
+
+// ResolveSecretRef 解析单个密钥引用
+func ResolveSecretRef(ref string, store *SecretStore) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, secretRefEnv):
+		name := strings.TrimPrefix(ref, secretRefEnv)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, secretRefFile):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, secretRefFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(ref, secretRefKeyring):
+		return store.Get(strings.TrimPrefix(ref, secretRefKeyring))
+	}
+	return ref, nil
+}
+
+// ResolveSecrets 将配置内容中凭据字段的密钥引用替换为实际值。
+// 只在生成 mihomo 实际加载的配置时调用，磁盘上的配置始终只保存引用。
+func ResolveSecrets(data []byte, store *SecretStore) ([]byte, error) {
+	// 没有任何引用时原样返回，避免重新编码
+	if !bytes.Contains(data, []byte(secretRefEnv)) &&
+		!bytes.Contains(data, []byte(secretRefFile)) &&
+		!bytes.Contains(data, []byte(secretRefKeyring)) {
+		return data, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var errs []string
+	resolved := 0
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if SensitiveKeys[key.Value] && value.Kind == yaml.ScalarNode && IsSecretRef(value.Value) {
+					secret, err := ResolveSecretRef(value.Value, store)
+					if err != nil {
+						errs = append(errs, fmt.Sprintf("%s (%s): %v", key.Value, value.Value, err))
+						continue
+					}
+					value.Value, value.Tag, value.Style = secret, "!!str", 0
+					resolved++
+				}
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(&doc)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to resolve secrets: %s", strings.Join(errs, "; "))
+	}
+	if resolved == 0 {
+		return data, nil
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out, nil
+}
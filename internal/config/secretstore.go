@@ -0,0 +1,233 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/pkg/utils"
+)
+
+const (
+	// SecretStoreFileName 加密密钥库文件名
+	SecretStoreFileName = "secrets.enc"
+
+	// SecretKeyFileName 密钥库的加密密钥文件名
+	SecretKeyFileName = "secrets.key"
+
+	// SecretKeyEnv 通过环境变量提供加密密钥（base64 编码的 32 字节），优先于密钥文件
+	SecretKeyEnv = EnvPrefix + "_SECRET_KEY"
+
+	// secretStoreVersion 密钥库文件格式版本
+	secretStoreVersion = 1
+)
+
+// ErrSecretNotFound 密钥不存在
+var ErrSecretNotFound = errors.New("secret not found")
+
+// secretName 合法的密钥名称
+var secretName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SecretStore 配置目录中的加密密钥库，供 keyring:name 引用。
+// 内容使用 AES-256-GCM 加密，密钥保存在 secrets.key（0600）或由环境变量提供；
+// 两个文件都不应提交到仓库。
+type SecretStore struct {
+	path    string
+	keyPath string
+}
+
+// NewSecretStore 创建密钥库
+func NewSecretStore(configDir string) *SecretStore {
+	return &SecretStore{
+		path:    filepath.Join(configDir, SecretStoreFileName),
+		keyPath: filepath.Join(configDir, SecretKeyFileName),
+	}
+}
+
+// Get 获取密钥
+func (s *SecretStore) Get(name string) (string, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+// Set 保存密钥，已存在时覆盖
+func (s *SecretStore) Set(name, value string) error {
+	if !secretName.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (letters, digits, '.', '_' and '-' only)", name)
+	}
+
+	unlock, err := utils.LockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return s.save(secrets)
+}
+
+// Delete 删除密钥
+func (s *SecretStore) Delete(name string) error {
+	unlock, err := utils.LockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	delete(secrets, name)
+	return s.save(secrets)
+}
+
+// List 列出所有密钥名称
+func (s *SecretStore) List() ([]string, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// load 读取并解密密钥库，文件不存在时返回空库
+func (s *SecretStore) load() (map[string]string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret store: %w", err)
+	}
+
+	aead, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 1+aead.NonceSize() || data[0] != secretStoreVersion {
+		return nil, fmt.Errorf("secret store %s is corrupt or has an unsupported format", s.path)
+	}
+	nonce, ciphertext := data[1:1+aead.NonceSize()], data[1+aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte{secretStoreVersion})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret store (wrong key?): %w", err)
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secret store: %w", err)
+	}
+	return secrets, nil
+}
+
+// save 加密并原子写入密钥库
+func (s *SecretStore) save(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	aead, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data := append([]byte{secretStoreVersion}, nonce...)
+	data = aead.Seal(data, nonce, plaintext, []byte{secretStoreVersion})
+
+	if err := utils.WriteFileAtomic(s.path, data, utils.PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write secret store: %w", err)
+	}
+	return nil
+}
+
+// cipher 获取加密密钥并创建 AES-GCM；create 为 true 时在密钥不存在时生成密钥文件
+func (s *SecretStore) cipher(create bool) (cipher.AEAD, error) {
+	key, err := s.key(create)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// key 读取加密密钥：环境变量优先，其次为密钥文件
+func (s *SecretStore) key(create bool) ([]byte, error) {
+	encoded, fromEnv := os.LookupEnv(SecretKeyEnv)
+	if !fromEnv {
+		data, err := os.ReadFile(s.keyPath)
+		switch {
+		case os.IsNotExist(err) && create:
+			return s.generateKey()
+		case os.IsNotExist(err):
+			return nil, fmt.Errorf("secret key not found: %s (set %s or restore the key file)", s.keyPath, SecretKeyEnv)
+		case err != nil:
+			return nil, fmt.Errorf("failed to read secret key: %w", err)
+		}
+		encoded = string(data)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid secret key: must be 32 bytes, base64 encoded")
+	}
+	return key, nil
+}
+
+// generateKey 生成新的加密密钥并写入密钥文件
+func (s *SecretStore) generateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := utils.WriteFileAtomic(s.keyPath, []byte(encoded), utils.PrivateFileMode); err != nil {
+		return nil, fmt.Errorf("failed to write secret key: %w", err)
+	}
+	return key, nil
+}
+
+// GetPath 获取密钥库文件路径
+func (s *SecretStore) GetPath() string {
+	return s.path
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// newKey 生成 base64 编码的随机加密密钥
+func newKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestSecretStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := NewSecretStore(dir)
+
+	if err := store.Set("hk-password", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("api.token", "t0ken"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开的密钥库使用同一个密钥文件
+	reopened := NewSecretStore(dir)
+	if value, err := reopened.Get("hk-password"); err != nil || value != "s3cret" {
+		t.Fatalf("Get = %q, %v", value, err)
+	}
+	names, err := reopened.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "api.token,hk-password" {
+		t.Errorf("List = %v", names)
+	}
+
+	data, err := os.ReadFile(store.GetPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Error("secret store contains plaintext")
+	}
+	for _, name := range []string{SecretStoreFileName, SecretKeyFileName} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s mode = %v, want 0600", name, info.Mode().Perm())
+		}
+	}
+
+	if err := reopened.Delete("hk-password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Get("hk-password"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrSecretNotFound", err)
+	}
+	if err := reopened.Delete("hk-password"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("second Delete = %v, want ErrSecretNotFound", err)
+	}
+	if err := reopened.Set("bad name", "x"); err == nil {
+		t.Error("expected error for invalid secret name")
+	}
+}
+
+func TestSecretStoreWrongKey(t *testing.T) {
+	dir := t.TempDir()
+	store := NewSecretStore(dir)
+	if err := store.Set("hk-password", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, SecretKeyFileName), []byte(newKey(t)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("hk-password"); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Fatalf("expected decryption error, got %v", err)
+	}
+
+	// 密钥文件丢失时不会生成新密钥覆盖原有内容
+	if err := os.Remove(filepath.Join(dir, SecretKeyFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("hk-password"); err == nil || !strings.Contains(err.Error(), "secret key not found") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
+
+func TestSecretStoreTampered(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(data []byte) []byte
+		want   string
+	}{
+		{"flipped ciphertext byte", func(data []byte) []byte {
+			data[len(data)-1] ^= 0xff
+			return data
+		}, "wrong key"},
+		{"unsupported version", func(data []byte) []byte {
+			data[0] = secretStoreVersion + 1
+			return data
+		}, "corrupt"},
+		{"truncated", func(data []byte) []byte {
+			return data[:5]
+		}, "corrupt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewSecretStore(t.TempDir())
+			if err := store.Set("hk-password", "s3cret"); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(store.GetPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(store.GetPath(), tt.tamper(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := store.Get("hk-password"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSecretStoreKeyFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	key := newKey(t)
+	t.Setenv(SecretKeyEnv, key)
+
+	store := NewSecretStore(dir)
+	if err := store.Set("hk-password", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SecretKeyFileName)); !os.IsNotExist(err) {
+		t.Fatalf("key file written although %s is set: %v", SecretKeyEnv, err)
+	}
+	if value, err := store.Get("hk-password"); err != nil || value != "s3cret" {
+		t.Fatalf("Get = %q, %v", value, err)
+	}
+
+	// 环境变量优先于密钥文件
+	if err := os.WriteFile(filepath.Join(dir, SecretKeyFileName), []byte(newKey(t)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("hk-password"); err != nil {
+		t.Fatalf("key file overrode %s: %v", SecretKeyEnv, err)
+	}
+
+	t.Setenv(SecretKeyEnv, newKey(t))
+	if _, err := store.Get("hk-password"); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Fatalf("expected decryption error with another key, got %v", err)
+	}
+
+	t.Setenv(SecretKeyEnv, "too-short")
+	if _, err := store.Get("hk-password"); err == nil || !strings.Contains(err.Error(), "must be 32 bytes") {
+		t.Fatalf("expected invalid key error, got %v", err)
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(SecretKeyEnv, newKey(t))
+	t.Setenv("CLASH_FISH_TEST_PASSWORD", "from-env")
+
+	secretFile := filepath.Join(dir, "uuid")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := NewSecretStore(dir)
+	if err := store.Set("jp", "from-keyring"); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`proxies:
+  - name: env:HK
+    type: ss
+    password: env:CLASH_FISH_TEST_PASSWORD
+  - name: JP
+    type: vless
+    uuid: file:` + secretFile + `
+  - name: US
+    type: trojan
+    password: keyring:jp
+secret: plain
+`)
+	out, err := ResolveSecrets(data, store)
+	if err != nil {
+		t.Fatalf("ResolveSecrets: %v", err)
+	}
+
+	var cfg struct {
+		Proxies []map[string]string `yaml:"proxies"`
+		Secret  string              `yaml:"secret"`
+	}
+	if err := yaml.Unmarshal(out, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	// 只替换凭据字段，其他字段（如节点名称）保持原样
+	checks := []struct{ got, want string }{
+		{cfg.Proxies[0]["password"], "from-env"},
+		{cfg.Proxies[0]["name"], "env:HK"},
+		{cfg.Proxies[1]["uuid"], "from-file"},
+		{cfg.Proxies[2]["password"], "from-keyring"},
+		{cfg.Secret, "plain"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("resolved value %q, want %q\n%s", c.got, c.want, out)
+		}
+	}
+
+	// 没有引用时原样返回
+	plain := []byte("secret: plain\n")
+	if out, err := ResolveSecrets(plain, store); err != nil || string(out) != string(plain) {
+		t.Errorf("ResolveSecrets without refs = %q, %v", out, err)
+	}
+}
+
+func TestResolveSecretsErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(SecretKeyEnv, newKey(t))
+
+	data := []byte(`proxies:
+  - name: HK
+    password: env:CLASH_FISH_TEST_UNSET
+  - name: JP
+    uuid: file:` + filepath.Join(dir, "missing") + `
+  - name: US
+    password: keyring:missing
+`)
+	_, err := ResolveSecrets(data, NewSecretStore(dir))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	// 所有无法解析的引用一次报告
+	for _, want := range []string{"CLASH_FISH_TEST_UNSET is not set", "failed to read secret file", "secret not found: missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/pkg/constants"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀，如 CLASH_FISH_PORT
+const EnvPrefix = "CLASH_FISH"
+
+// 设置项键名，与 config.yaml 中的字段名一致
+const (
+	KeyConfigDir  = "config-dir"
+	KeyLogDir     = "log-dir"
+	KeyLogLevel   = "log-level"
+	KeyPort       = "port"
+	KeySocksPort  = "socks-port"
+	KeyController = "external-controller"
+)
+
+// settingKeys 所有设置项，按显示顺序排列
+var settingKeys = []string{KeyConfigDir, KeyLogDir, KeyLogLevel, KeyPort, KeySocksPort, KeyController}
+
+// settingFlags 设置项对应的命令行标志名
+var settingFlags = map[string]string{
+	KeyConfigDir:  "config-dir",
+	KeyLogDir:     "log-dir",
+	KeyLogLevel:   "log-level",
+	KeyPort:       "port",
+	KeySocksPort:  "socks-port",
+	KeyController: "controller",
+}
+
+// mihomoKeys 同时作用于 mihomo 配置的设置项
+var mihomoKeys = []string{KeyLogLevel, KeyPort, KeySocksPort, KeyController}
+
+// Origin 设置项的来源
+type Origin string
+
+const (
+	OriginDefault Origin = "default"
+	OriginFile    Origin = "file"
+	OriginEnv     Origin = "env"
+	OriginFlag    Origin = "flag"
+)
+
+// Settings clash-fish 自身的运行设置，按 默认值 → YAML → 环境变量 → 命令行标志 逐层覆盖
+type Settings struct {
+	ConfigDir          string
+	LogDir             string
+	LogLevel           string
+	Port               int
+	SocksPort          int
+	ExternalController string
+
+	origins  map[string]Origin
+	values   map[string]interface{}
+	warnings []string
+}
+
+// LoadAndBind 加载分层设置。flags 中存在且被显式设置的标志优先级最高；
+// config-dir 只能来自默认值、环境变量或标志（YAML 位于该目录中）。
+// config.yaml 无法解析时忽略文件中的设置并记录告警，保证修复命令仍可运行。
+func LoadAndBind(flags *pflag.FlagSet) (*Settings, error) {
+	v := viper.New()
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+
+	defaults := GetDefaultConfig()
+	v.SetDefault(KeyConfigDir, constants.GetDefaultConfigDir())
+	v.SetDefault(KeyLogLevel, defaults.LogLevel)
+	v.SetDefault(KeyPort, defaults.Port)
+	v.SetDefault(KeySocksPort, defaults.SocksPort)
+	v.SetDefault(KeyController, defaults.ExternalController)
+
+	for _, key := range settingKeys {
+		if err := v.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("failed to bind env for %s: %w", key, err)
+		}
+		if flags == nil {
+			continue
+		}
+		if flag := flags.Lookup(settingFlags[key]); flag != nil {
+			if err := v.BindPFlag(key, flag); err != nil {
+				return nil, fmt.Errorf("failed to bind flag --%s: %w", flag.Name, err)
+			}
+		}
+	}
+
+	// 先确定配置目录，再读取其中的 config.yaml
+	configDir := v.GetString(KeyConfigDir)
+	v.SetDefault(KeyLogDir, filepath.Join(configDir, "logs"))
+
+	v.SetConfigFile(filepath.Join(configDir, constants.DefaultConfigFileName))
+	v.SetConfigType("yaml")
+	var warnings []string
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) && !os.IsNotExist(err) {
+			warnings = append(warnings, fmt.Sprintf("ignoring settings in %s: %v", v.ConfigFileUsed(), err))
+		}
+	}
+
+	s := &Settings{
+		ConfigDir:          configDir,
+		LogDir:             v.GetString(KeyLogDir),
+		LogLevel:           v.GetString(KeyLogLevel),
+		Port:               v.GetInt(KeyPort),
+		SocksPort:          v.GetInt(KeySocksPort),
+		ExternalController: v.GetString(KeyController),
+		origins:            make(map[string]Origin),
+		warnings:           warnings,
+	}
+	s.values = map[string]interface{}{
+		KeyConfigDir:  s.ConfigDir,
+		KeyLogDir:     s.LogDir,
+		KeyLogLevel:   s.LogLevel,
+		KeyPort:       s.Port,
+		KeySocksPort:  s.SocksPort,
+		KeyController: s.ExternalController,
+	}
+
+	for _, key := range settingKeys {
+		s.origins[key] = resolveOrigin(v, flags, key)
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// resolveOrigin 判断设置项最终取值的来源
+func resolveOrigin(v *viper.Viper, flags *pflag.FlagSet, key string) Origin {
+	if flags != nil {
+		if flag := flags.Lookup(settingFlags[key]); flag != nil && flag.Changed {
+			return OriginFlag
+		}
+	}
+	if _, ok := os.LookupEnv(EnvName(key)); ok {
+		return OriginEnv
+	}
+	if key != KeyConfigDir && v.InConfig(key) {
+		return OriginFile
+	}
+	return OriginDefault
+}
+
+// validate 检查来自环境变量和标志的取值，错误信息注明来源便于定位。
+// config.yaml 中的取值由配置校验负责，这里不拦截，以免无法运行修复命令。
+func (s *Settings) validate() error {
+	overridden := func(key string) bool {
+		origin := s.Origin(key)
+		return origin == OriginEnv || origin == OriginFlag
+	}
+
+	for _, key := range []string{KeyPort, KeySocksPort} {
+		if port := s.values[key].(int); overridden(key) && (port <= 0 || port > 65535) {
+			raw := fmt.Sprint(port)
+			if s.Origin(key) == OriginEnv {
+				raw = os.Getenv(EnvName(key))
+			}
+			return fmt.Errorf("invalid %s from %s: %s (must be 1-65535)", key, s.Origin(key), raw)
+		}
+	}
+	if overridden(KeyLogLevel) && !validLogLevels[s.LogLevel] {
+		return fmt.Errorf("invalid %s from %s: %s (must be debug/info/warning/error/silent)", KeyLogLevel, s.Origin(KeyLogLevel), s.LogLevel)
+	}
+	return nil
+}
+
+// Warnings 加载过程中的告警，如 config.yaml 解析失败
+func (s *Settings) Warnings() []string {
+	return s.warnings
+}
+
+// Keys 所有设置项键名
+func (s *Settings) Keys() []string {
+	return settingKeys
+}
+
+// Value 获取设置项的值
+func (s *Settings) Value(key string) interface{} {
+	return s.values[key]
+}
+
+// Origin 获取设置项的来源
+func (s *Settings) Origin(key string) Origin {
+	return s.origins[key]
+}
+
+// Overrides 由环境变量或标志覆盖、需要叠加到 mihomo 配置上的字段
+func (s *Settings) Overrides() map[string]interface{} {
+	overrides := make(map[string]interface{})
+	for _, key := range mihomoKeys {
+		if origin := s.origins[key]; origin == OriginEnv || origin == OriginFlag {
+			overrides[key] = s.values[key]
+		}
+	}
+	return overrides
+}
+
+// EnvName 设置项对应的环境变量名
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// FlagName 设置项对应的命令行标志名
+func FlagName(key string) string {
+	return settingFlags[key]
+}
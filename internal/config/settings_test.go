@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/clash-fish/clash-fish/pkg/constants"
+	"github.com/spf13/pflag"
+)
+
+func TestLoadAndBindMalformedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, constants.DefaultConfigFileName)
+	if err := os.WriteFile(path, []byte("port: [7890\nlog-level: debug\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvName(KeyConfigDir), dir)
+	t.Setenv(EnvName(KeySocksPort), "1080")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("log-level", "", "")
+	if err := flags.Parse([]string{"--log-level", "error"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadAndBind(flags)
+	if err != nil {
+		t.Fatalf("LoadAndBind: %v", err)
+	}
+	if len(s.Warnings()) != 1 || !strings.Contains(s.Warnings()[0], path) {
+		t.Fatalf("expected a warning about %s, got %v", path, s.Warnings())
+	}
+
+	// 文件被忽略，其余各层照常生效
+	defaults := GetDefaultConfig()
+	if s.Port != defaults.Port || s.Origin(KeyPort) != OriginDefault {
+		t.Errorf("port = %d from %s, want default %d", s.Port, s.Origin(KeyPort), defaults.Port)
+	}
+	if s.SocksPort != 1080 || s.Origin(KeySocksPort) != OriginEnv {
+		t.Errorf("socks-port = %d from %s, want 1080 from env", s.SocksPort, s.Origin(KeySocksPort))
+	}
+	if s.LogLevel != "error" || s.Origin(KeyLogLevel) != OriginFlag {
+		t.Errorf("log-level = %s from %s, want error from flag", s.LogLevel, s.Origin(KeyLogLevel))
+	}
+	if s.ConfigDir != dir {
+		t.Errorf("config-dir = %s, want %s", s.ConfigDir, dir)
+	}
+}
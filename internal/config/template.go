@@ -1,8 +1,14 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+)
+
 // GetDefaultConfig 返回默认配置
 func GetDefaultConfig() *Config {
 	return &Config{
+		Version:            CurrentVersion,
 		Port:               7890,
 		SocksPort:          7891,
 		AllowLan:           false,
@@ -59,6 +65,168 @@ func GetDefaultConfig() *Config {
 	}
 }
 
+// GetDefaultConfigWithProxies 返回使用指定代理节点的默认配置，
+// 所有节点加入默认的 PROXY 选择组
+func GetDefaultConfigWithProxies(proxies []Proxy) *Config {
+	config := GetDefaultConfig()
+	config.Proxies = proxies
+
+	names := make([]string, 0, len(proxies)+1)
+	for _, p := range proxies {
+		names = append(names, p.Name)
+	}
+	names = append(names, "DIRECT")
+
+	config.ProxyGroups = []ProxyGroup{
+		{
+			Name:    "PROXY",
+			Type:    "select",
+			Proxies: names,
+		},
+	}
+
+	return config
+}
+
+// Template 内置配置模板
+type Template struct {
+	Name        string
+	Description string
+	Build       func() *Config
+}
+
+// DefaultTemplate 默认模板名称
+const DefaultTemplate = "default"
+
+// templates 内置模板，按显示顺序排列
+var templates = []Template{
+	{
+		Name:        DefaultTemplate,
+		Description: "TUN transparent proxy with fake-ip DNS (requires root)",
+		Build:       GetDefaultConfig,
+	},
+	{
+		Name:        "tun-vpn",
+		Description: "TUN that coexists with a corporate VPN: private ranges bypass TUN, no strict route",
+		Build:       tunVPNTemplate,
+	},
+	{
+		Name:        "system-proxy",
+		Description: "HTTP/SOCKS proxy only, no TUN and no root; point the system proxy at 127.0.0.1:7890",
+		Build:       systemProxyTemplate,
+	},
+	{
+		Name:        "gaming",
+		Description: "Low-latency TUN for games: mixed stack, no strict route, url-test picks the fastest node",
+		Build:       gamingTemplate,
+	},
+	{
+		Name:        "lan-gateway",
+		Description: "Gateway for other LAN devices: allow-lan, DNS on 0.0.0.0:53, TUN auto-route",
+		Build:       lanGatewayTemplate,
+	},
+	{
+		Name:        "direct",
+		Description: "Everything direct, no TUN and no DNS; for testing the service itself",
+		Build:       directTemplate,
+	},
+}
+
+// Templates 返回所有内置模板
+func Templates() []Template {
+	return templates
+}
+
+// GetTemplate 按名称获取内置模板
+func GetTemplate(name string) (*Template, error) {
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], nil
+		}
+	}
+
+	names := make([]string, 0, len(templates))
+	for _, t := range templates {
+		names = append(names, t.Name)
+	}
+	return nil, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// privateRanges 局域网和常见 VPN 内网网段
+var privateRanges = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+}
+
+// tunVPNTemplate TUN 与公司 VPN 共存：内网网段不进入 TUN，内网域名返回真实 IP
+func tunVPNTemplate() *Config {
+	config := GetDefaultConfig()
+	config.TUN.StrictRoute = false
+	config.TUN.RouteExcludeAddress = append([]string{}, privateRanges...)
+	config.DNS.FakeIPFilter = []string{
+		"*.lan",
+		"*.local",
+		"*.internal",
+		"+.corp",
+	}
+	return config
+}
+
+// systemProxyTemplate 仅 HTTP/SOCKS 代理，不需要 root
+func systemProxyTemplate() *Config {
+	config := GetDefaultConfig()
+	config.TUN = TUNConfig{Stack: "system"}
+	config.DNS.Enable = false
+	return config
+}
+
+// gamingTemplate 低延迟游戏：mixed 协议栈（UDP 走 gvisor），不锁定路由，自动选择延迟最低的节点
+func gamingTemplate() *Config {
+	config := GetDefaultConfig()
+	config.UnifiedDelay = true
+	config.TCPConcurrent = true
+	config.TUN.Stack = "mixed"
+	config.TUN.StrictRoute = false
+	config.ProxyGroups = []ProxyGroup{
+		{
+			Name:    "PROXY",
+			Type:    "select",
+			Proxies: []string{"AUTO", "DIRECT"},
+		},
+		{
+			Name:      "AUTO",
+			Type:      "url-test",
+			Proxies:   []string{"example-proxy"},
+			URL:       "https://www.gstatic.com/generate_204",
+			Interval:  300,
+			Tolerance: 20,
+		},
+	}
+	return config
+}
+
+// lanGatewayTemplate 局域网网关：其他设备将网关和 DNS 指向本机
+func lanGatewayTemplate() *Config {
+	config := GetDefaultConfig()
+	config.AllowLan = true
+	config.DNS.Listen = "0.0.0.0:53"
+	return config
+}
+
+// directTemplate 全部直连，用于测试服务本身
+func directTemplate() *Config {
+	config := GetDefaultConfig()
+	config.Mode = "direct"
+	config.TUN = TUNConfig{Stack: "system"}
+	config.DNS.Enable = false
+	config.Proxies = nil
+	config.ProxyGroups = nil
+	config.Rules = []string{"MATCH,DIRECT"}
+	return config
+}
+
 // GetExampleConfig 返回示例配置（带注释说明）
 func GetExampleConfig() string {
 	return `# Clash-Fish Configuration File
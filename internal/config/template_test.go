@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestTemplatesAreValid(t *testing.T) {
+	for _, template := range Templates() {
+		t.Run(template.Name, func(t *testing.T) {
+			cfg := template.Build()
+			if err := Validate(cfg); err != nil {
+				t.Fatalf("template is invalid: %v", err)
+			}
+			if cfg.Version != CurrentVersion {
+				t.Errorf("version = %d, want %d", cfg.Version, CurrentVersion)
+			}
+
+			// config init 写入的是编码后的内容，编码后也必须通过校验
+			data, err := yaml.Marshal(cfg)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			issues, err := ValidateData(data)
+			if err != nil {
+				t.Fatalf("ValidateData: %v", err)
+			}
+			if HasErrors(issues) {
+				t.Fatalf("encoded template is invalid: %v", issues)
+			}
+		})
+	}
+}
+
+func TestGetTemplateUnknown(t *testing.T) {
+	if _, err := GetTemplate("no-such-template"); err == nil {
+		t.Fatal("expected an error for an unknown template")
+	}
+	if template, err := GetTemplate(DefaultTemplate); err != nil || template.Name != DefaultTemplate {
+		t.Fatalf("GetTemplate(%q) = %v, %v", DefaultTemplate, template, err)
+	}
+}
@@ -2,12 +2,15 @@ package config
 
 // Config 主配置结构
 type Config struct {
+	Version            int          `yaml:"clash-fish-version,omitempty"` // 配置格式版本，见 migrate.go
 	Port               int          `yaml:"port"`
 	SocksPort          int          `yaml:"socks-port"`
 	AllowLan           bool         `yaml:"allow-lan"`
 	Mode               string       `yaml:"mode"`
 	LogLevel           string       `yaml:"log-level"`
 	ExternalController string       `yaml:"external-controller"`
+	UnifiedDelay       bool         `yaml:"unified-delay,omitempty"`
+	TCPConcurrent      bool         `yaml:"tcp-concurrent,omitempty"`
 	TUN                TUNConfig    `yaml:"tun"`
 	DNS                DNSConfig    `yaml:"dns"`
 	Proxies            []Proxy      `yaml:"proxies"`
@@ -22,6 +25,8 @@ type TUNConfig struct {
 	DNSHijack           []string `yaml:"dns-hijack"`
 	AutoRoute           bool     `yaml:"auto-route"`
 	AutoDetectInterface bool     `yaml:"auto-detect-interface"`
+	StrictRoute         bool     `yaml:"strict-route,omitempty"`
+	RouteExcludeAddress []string `yaml:"route-exclude-address,omitempty"` // 不经过 TUN 的网段（如公司 VPN 内网）
 }
 
 // DNSConfig DNS 配置
@@ -30,28 +35,69 @@ type DNSConfig struct {
 	Listen       string   `yaml:"listen"`
 	EnhancedMode string   `yaml:"enhanced-mode"`
 	FakeIPRange  string   `yaml:"fake-ip-range"`
+	FakeIPFilter []string `yaml:"fake-ip-filter,omitempty"` // 返回真实 IP 的域名
 	Nameserver   []string `yaml:"nameserver"`
 	Fallback     []string `yaml:"fallback"`
 }
 
 // Proxy 代理配置
 type Proxy struct {
-	Name     string                 `yaml:"name"`
-	Type     string                 `yaml:"type"`
-	Server   string                 `yaml:"server"`
-	Port     int                    `yaml:"port"`
-	Cipher   string                 `yaml:"cipher,omitempty"`
-	Password string                 `yaml:"password,omitempty"`
-	UDP      bool                   `yaml:"udp,omitempty"`
-	Plugin   string                 `yaml:"plugin,omitempty"`
-	PluginOpts map[string]interface{} `yaml:"plugin-opts,omitempty"`
+	Name              string                 `yaml:"name"`
+	Type              string                 `yaml:"type"`
+	Server            string                 `yaml:"server"`
+	Port              int                    `yaml:"port"`
+	Cipher            string                 `yaml:"cipher,omitempty"`
+	Username          string                 `yaml:"username,omitempty"` // http/socks5 认证
+	Password          string                 `yaml:"password,omitempty"`
+	UDP               bool                   `yaml:"udp,omitempty"`
+	Plugin            string                 `yaml:"plugin,omitempty"`
+	PluginOpts        map[string]interface{} `yaml:"plugin-opts,omitempty"`
+	UUID              string                 `yaml:"uuid,omitempty"`
+	AlterID           int                    `yaml:"alterId,omitempty"`
+	Flow              string                 `yaml:"flow,omitempty"`
+	Network           string                 `yaml:"network,omitempty"`
+	TLS               bool                   `yaml:"tls,omitempty"`
+	ServerName        string                 `yaml:"servername,omitempty"`
+	SNI               string                 `yaml:"sni,omitempty"`
+	ALPN              []string               `yaml:"alpn,omitempty"`
+	SkipCertVerify    bool                   `yaml:"skip-cert-verify,omitempty"`
+	ClientFingerprint string                 `yaml:"client-fingerprint,omitempty"`
+	WSOpts            *WSOptions             `yaml:"ws-opts,omitempty"`
+	GRPCOpts          *GRPCOptions           `yaml:"grpc-opts,omitempty"`
+	RealityOpts       *RealityOptions        `yaml:"reality-opts,omitempty"`
+	Obfs              string                 `yaml:"obfs,omitempty"`
+	ObfsPassword      string                 `yaml:"obfs-password,omitempty"`
+	Up                string                 `yaml:"up,omitempty"`
+	Down              string                 `yaml:"down,omitempty"`
+}
+
+// WSOptions WebSocket 传输配置（vmess/vless/trojan）
+type WSOptions struct {
+	Path    string            `yaml:"path,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// GRPCOptions gRPC 传输配置（vmess/vless/trojan）
+type GRPCOptions struct {
+	GRPCServiceName string `yaml:"grpc-service-name,omitempty"`
+}
+
+// RealityOptions REALITY 配置（vless）
+type RealityOptions struct {
+	PublicKey string `yaml:"public-key"`
+	ShortID   string `yaml:"short-id,omitempty"`
 }
 
 // ProxyGroup 代理组配置
 type ProxyGroup struct {
-	Name    string   `yaml:"name"`
-	Type    string   `yaml:"type"`
-	Proxies []string `yaml:"proxies"`
-	URL     string   `yaml:"url,omitempty"`
-	Interval int     `yaml:"interval,omitempty"`
+	Name                string   `yaml:"name"`
+	Type                string   `yaml:"type"`
+	Proxies             []string `yaml:"proxies"`
+	Use                 []string `yaml:"use,omitempty"`                   // 引用的 proxy-providers
+	IncludeAll          bool     `yaml:"include-all,omitempty"`           // 自动包含所有节点和 providers
+	IncludeAllProxies   bool     `yaml:"include-all-proxies,omitempty"`   // 自动包含所有节点
+	IncludeAllProviders bool     `yaml:"include-all-providers,omitempty"` // 自动包含所有 providers
+	URL                 string   `yaml:"url,omitempty"`
+	Interval            int      `yaml:"interval,omitempty"`
+	Tolerance           int      `yaml:"tolerance,omitempty"` // url-test 切换节点的延迟容差（毫秒）
 }
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity 问题严重程度
+type Severity string
+
+const (
+	// SeverityError 配置无法使用
+	SeverityError Severity = "error"
+
+	// SeverityWarning 配置可用但可能不符合预期
+	SeverityWarning Severity = "warning"
+)
+
+// Issue 单个校验问题
+type Issue struct {
+	Path     string   `json:"path"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Hint     string   `json:"hint,omitempty"`
+}
+
+// String 格式化问题，如 "proxy-groups[2].proxies[1] (line 12, column 7): ..."
+func (i Issue) String() string {
+	location := i.Path
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s (line %d, column %d)", i.Path, i.Line, i.Column)
+	}
+	return fmt.Sprintf("%s: %s", location, i.Message)
+}
+
+// ValidationError 校验失败，包含所有问题（含警告）
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	var errs []Issue
+	for _, issue := range e.Issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0].String()
+	}
+	return fmt.Sprintf("%s (and %d more error(s))", errs[0].String(), len(errs)-1)
+}
+
+// HasErrors 检查问题列表中是否有错误级别的问题
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// validLogLevels 合法的日志级别
+var validLogLevels = map[string]bool{
+	"info":    true,
+	"warning": true,
+	"error":   true,
+	"debug":   true,
+	"silent":  true,
+}
+
+// validModes 合法的代理模式
+var validModes = map[string]bool{
+	"rule":   true,
+	"global": true,
+	"direct": true,
+}
+
+// validStacks 合法的 TUN 协议栈
+var validStacks = map[string]bool{
+	"system": true,
+	"gvisor": true,
+	"mixed":  true,
+}
+
+// validEnhancedModes 合法的 DNS 增强模式
+var validEnhancedModes = map[string]bool{
+	"fake-ip":    true,
+	"redir-host": true,
+}
+
+// Validate 验证配置文件，有错误时返回包含所有问题的 *ValidationError
+func Validate(config *Config) error {
+	issues := Check(config)
+	if !HasErrors(issues) {
+		return nil
+	}
+	return &ValidationError{Issues: issues}
+}
+
+// ValidateData 解析并验证配置内容，问题附带 YAML 中的行列号。
+// 只有内容无法解析时才返回 error。
+func ValidateData(data []byte) ([]Issue, error) {
+	root, config, err := decodeData(data)
+	if err != nil {
+		return nil, err
+	}
+	return attachPositions(root, Check(config)), nil
+}
+
+// decodeData 解析配置内容为 YAML 节点和 Config 结构
+func decodeData(data []byte) (*yaml.Node, *Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var config Config
+	if root.Kind != 0 {
+		if err := root.Decode(&config); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+	return &root, &config, nil
+}
+
+// attachPositions 为问题补充 YAML 中的行列号
+func attachPositions(root *yaml.Node, issues []Issue) []Issue {
+	for i := range issues {
+		if node := locate(root, issues[i].Path); node != nil {
+			issues[i].Line = node.Line
+			issues[i].Column = node.Column
+		}
+	}
+	return issues
+}
+
+// Check 一次收集配置中的所有问题
+func Check(config *Config) []Issue {
+	c := &checker{}
+
+	if config.Version > CurrentVersion {
+		c.errorf(VersionKey, "upgrade clash-fish", "config was written by a newer clash-fish (version %d, this build supports %d)", config.Version, CurrentVersion)
+	}
+
+	c.port("port", config.Port)
+	c.port("socks-port", config.SocksPort)
+
+	if !validModes[config.Mode] {
+		c.errorf("mode", "use one of: rule, global, direct", "invalid mode %q", config.Mode)
+	}
+	if !validLogLevels[config.LogLevel] {
+		c.errorf("log-level", "use one of: debug, info, warning, error, silent", "invalid log-level %q", config.LogLevel)
+	}
+
+	if config.TUN.Enable {
+		if !validStacks[config.TUN.Stack] {
+			c.errorf("tun.stack", "use one of: system, gvisor, mixed", "invalid tun stack %q", config.TUN.Stack)
+		}
+		if !config.DNS.Enable {
+			c.warnf("dns.enable", "enable dns so that hijacked DNS queries are answered by mihomo", "TUN is enabled but DNS is disabled")
+		}
+	}
+
+	if config.DNS.Enable {
+		if !validEnhancedModes[config.DNS.EnhancedMode] {
+			c.errorf("dns.enhanced-mode", "use fake-ip or redir-host", "invalid dns enhanced-mode %q", config.DNS.EnhancedMode)
+		}
+		if len(config.DNS.Nameserver) == 0 {
+			c.errorf("dns.nameserver", "add at least one upstream, e.g. 223.5.5.5", "dns is enabled but no nameserver is configured")
+		}
+	}
+
+	for i, p := range config.Proxies {
+		path := fmt.Sprintf("proxies[%d]", i)
+		if p.Name == "" {
+			c.errorf(path+".name", "give every proxy a unique name", "proxy has no name")
+		}
+		if p.Type == "" {
+			c.errorf(path+".type", "set the protocol, e.g. ss, vmess, trojan", "proxy %q has no type", p.Name)
+		}
+		if p.Server == "" {
+			c.errorf(path+".server", "set the server hostname or IP", "proxy %q has no server", p.Name)
+		}
+		c.port(path+".port", p.Port)
+	}
+
+	for i, g := range config.ProxyGroups {
+		path := fmt.Sprintf("proxy-groups[%d]", i)
+		if g.Name == "" {
+			c.errorf(path+".name", "give every proxy group a unique name", "proxy group has no name")
+		}
+		if g.Type == "" {
+			c.errorf(path+".type", "set the group type, e.g. select, url-test, fallback", "proxy group %q has no type", g.Name)
+		}
+	}
+
+	c.checkReferences(config)
+
+	if len(config.Rules) == 0 {
+		c.warnf("rules", "add a final MATCH rule, e.g. MATCH,DIRECT", "no rules configured")
+	}
+
+	return c.issues
+}
+
+// checker 校验问题收集器
+type checker struct {
+	issues []Issue
+}
+
+// errorf 记录错误
+func (c *checker) errorf(path, hint, format string, args ...interface{}) {
+	c.add(SeverityError, path, hint, format, args...)
+}
+
+// warnf 记录警告
+func (c *checker) warnf(path, hint, format string, args ...interface{}) {
+	c.add(SeverityWarning, path, hint, format, args...)
+}
+
+// add 记录问题
+func (c *checker) add(severity Severity, path, hint, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Hint:     hint,
+	})
+}
+
+// port 检查端口范围
+func (c *checker) port(path string, port int) {
+	if port <= 0 || port > 65535 {
+		c.errorf(path, "use a port between 1 and 65535", "invalid %s: %d", path[strings.LastIndex(path, ".")+1:], port)
+	}
+}
+
+// locate 按字段路径（如 proxy-groups[2].proxies[1]）查找 YAML 节点，
+// 路径不存在（如缺失的字段）时返回 nil；映射或列表类型的字段返回其键节点
+func locate(root *yaml.Node, path string) *yaml.Node {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	var keyNode *yaml.Node
+	for _, segment := range strings.Split(path, ".") {
+		key, indexes := splitSegment(segment)
+
+		idx := mappingIndex(node, key)
+		if idx < 0 {
+			return nil
+		}
+		keyNode, node = node.Content[idx], node.Content[idx+1]
+
+		for _, i := range indexes {
+			if node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				return nil
+			}
+			keyNode, node = nil, node.Content[i]
+		}
+	}
+
+	if node.Kind != yaml.ScalarNode && keyNode != nil {
+		return keyNode
+	}
+	return node
+}
+
+// splitSegment 拆分路径片段，如 "proxies[1]" -> ("proxies", [1])
+func splitSegment(segment string) (string, []int) {
+	key, rest, found := strings.Cut(segment, "[")
+	if !found {
+		return segment, nil
+	}
+
+	var indexes []int
+	for _, part := range strings.Split(rest, "[") {
+		n, err := strconv.Atoi(strings.TrimSuffix(part, "]"))
+		if err != nil {
+			break
+		}
+		indexes = append(indexes, n)
+	}
+	return key, indexes
+}
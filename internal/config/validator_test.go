@@ -0,0 +1,41 @@
+package config
+
+import "testing"
+
+func TestValidateDataPositions(t *testing.T) {
+	data := []byte(`mixed-port: 7890
+socks-port: 70000
+mode: rule
+log-level: info
+proxy-groups:
+  - name: Proxy
+    type: select
+    proxies: [DIRECT, Missing]
+rules:
+  - MATCH,Proxy
+`)
+	issues, err := ValidateData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 缺失的字段只报告路径，不指向文件开头
+	want := map[string]string{
+		"port":                       "port: invalid port: 0",
+		"socks-port":                 "socks-port (line 2, column 13): invalid socks-port: 70000",
+		"proxy-groups[0].proxies[1]": `proxy-groups[0].proxies[1] (line 8, column 23): group "Proxy" references unknown proxy or group "Missing"`,
+	}
+	for _, issue := range issues {
+		expected, ok := want[issue.Path]
+		if !ok {
+			continue
+		}
+		if got := issue.String(); got != expected {
+			t.Errorf("issue = %q, want %q", got, expected)
+		}
+		delete(want, issue.Path)
+	}
+	for path := range want {
+		t.Errorf("missing issue for %s", path)
+	}
+}
@@ -0,0 +1,363 @@
+// Package convert 在 clash-fish 配置与其他客户端（Surge、sing-box、Quantumult X）的配置格式之间转换
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+// Format 外部配置格式
+type Format string
+
+const (
+	// FormatSurge Surge .conf 配置
+	FormatSurge Format = "surge"
+
+	// FormatSingBox sing-box JSON 配置
+	FormatSingBox Format = "singbox"
+
+	// FormatQuantumultX Quantumult X 配置
+	FormatQuantumultX Format = "qx"
+)
+
+// ImportFormats 支持导入的格式
+var ImportFormats = []Format{FormatSurge, FormatSingBox, FormatQuantumultX}
+
+// ExportFormats 支持导出的格式
+var ExportFormats = []Format{FormatSingBox}
+
+// Skipped 无法转换（或只能部分转换）的条目
+type Skipped struct {
+	Section string `json:"section"` // proxy、group、rule 或原配置中的段落名
+	Item    string `json:"item"`
+	Reason  string `json:"reason"`
+}
+
+// Report 转换报告
+type Report struct {
+	Proxies int       `json:"proxies"`
+	Groups  int       `json:"groups"`
+	Rules   int       `json:"rules"`
+	Skipped []Skipped `json:"skipped,omitempty"`
+}
+
+// skip 记录无法转换的条目
+func (r *Report) skip(section, item, format string, args ...interface{}) {
+	r.Skipped = append(r.Skipped, Skipped{Section: section, Item: item, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Import 将外部格式的配置转换为 clash-fish 配置：转换代理、代理组和规则，
+// 其余设置（端口、TUN、DNS）使用默认配置。无法转换的内容记录在报告中。
+func Import(format Format, data []byte) (*config.Config, *Report, error) {
+	b := newBuilder()
+
+	var err error
+	switch format {
+	case FormatSurge:
+		err = importSurge(b, data)
+	case FormatSingBox:
+		err = importSingBox(b, data)
+	case FormatQuantumultX:
+		err = importQuantumultX(b, data)
+	default:
+		return nil, nil, fmt.Errorf("unsupported import format %q (supported: %s)", format, formatList(ImportFormats))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b.finish()
+}
+
+// Export 将 clash-fish 配置转换为外部格式，无法转换的内容记录在报告中
+func Export(format Format, cfg *config.Config) ([]byte, *Report, error) {
+	switch format {
+	case FormatSingBox:
+		return exportSingBox(cfg)
+	default:
+		return nil, nil, fmt.Errorf("unsupported export format %q (supported: %s)", format, formatList(ExportFormats))
+	}
+}
+
+// formatList 格式列表的文本形式
+func formatList(formats []Format) string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// builder 逐条收集转换结果，最后统一解析组成员和规则目标的引用
+type builder struct {
+	config  *config.Config
+	report  *Report
+	aliases map[string]string // 原配置中的策略名 -> 内置出站（如 Surge 中 type=direct 的节点）
+	names   map[string]bool   // 已转换的代理和代理组名称
+	rules   []pendingRule
+}
+
+// pendingRule 等待解析目标的规则
+type pendingRule struct {
+	rule   string
+	source string // 原配置中的写法，用于报告
+}
+
+func newBuilder() *builder {
+	cfg := config.GetDefaultConfig()
+	cfg.Proxies, cfg.ProxyGroups, cfg.Rules = nil, nil, nil
+	return &builder{
+		config:  cfg,
+		report:  &Report{},
+		aliases: make(map[string]string),
+		names:   make(map[string]bool),
+	}
+}
+
+// alias 将原配置中的策略名映射为内置出站
+func (b *builder) alias(name, target string) {
+	b.aliases[name] = target
+}
+
+// addProxy 添加代理，名称与已有代理或代理组重复时跳过
+func (b *builder) addProxy(proxy *config.Proxy, source string) {
+	if b.names[proxy.Name] {
+		b.report.skip("proxy", source, "duplicate name %q", proxy.Name)
+		return
+	}
+	b.names[proxy.Name] = true
+	b.config.Proxies = append(b.config.Proxies, *proxy)
+}
+
+// addGroup 添加代理组，成员在 finish 时解析
+func (b *builder) addGroup(group *config.ProxyGroup, source string) {
+	if b.names[group.Name] {
+		b.report.skip("group", source, "duplicate name %q", group.Name)
+		return
+	}
+	b.names[group.Name] = true
+	b.config.ProxyGroups = append(b.config.ProxyGroups, *group)
+}
+
+// addRule 添加规则，目标在 finish 时解析
+func (b *builder) addRule(rule, source string) {
+	b.rules = append(b.rules, pendingRule{rule: rule, source: source})
+}
+
+// resolve 解析策略名：已转换的代理/代理组、别名或内置出站
+func (b *builder) resolve(name string) (string, bool) {
+	if b.names[name] {
+		return name, true
+	}
+	if target, ok := b.aliases[name]; ok {
+		return target, true
+	}
+	if config.BuiltinTargets[strings.ToUpper(name)] {
+		return strings.ToUpper(name), true
+	}
+	return "", false
+}
+
+// finish 解析引用并生成配置：组内未知成员被移除，目标未知或格式无效的规则被跳过
+func (b *builder) finish() (*config.Config, *Report, error) {
+	cfg := b.config
+	if len(cfg.Proxies) == 0 && len(cfg.ProxyGroups) == 0 {
+		return nil, nil, fmt.Errorf("no proxies or proxy groups found")
+	}
+
+	for i := range cfg.ProxyGroups {
+		group := &cfg.ProxyGroups[i]
+		members := make([]string, 0, len(group.Proxies))
+		for _, name := range group.Proxies {
+			target, ok := b.resolve(name)
+			if !ok {
+				b.report.skip("group", group.Name, "unknown member %q removed", name)
+				continue
+			}
+			members = append(members, target)
+		}
+		if len(members) == 0 && !group.IncludeAll && !group.IncludeAllProxies {
+			b.report.skip("group", group.Name, "no usable members, DIRECT added")
+			members = append(members, "DIRECT")
+		}
+		group.Proxies = members
+	}
+
+	// 没有代理组时与分享链接转换一致：所有节点加入 PROXY 选择组
+	if len(cfg.ProxyGroups) == 0 {
+		cfg.ProxyGroups = config.GetDefaultConfigWithProxies(cfg.Proxies).ProxyGroups
+		b.names[cfg.ProxyGroups[0].Name] = true
+	}
+
+	hasMatch := false
+	for _, pending := range b.rules {
+		rule, err := config.ParseRule(pending.rule)
+		if err != nil {
+			b.report.skip("rule", pending.source, "%v", err)
+			continue
+		}
+		target, ok := b.resolve(rule.Target)
+		if !ok {
+			b.report.skip("rule", pending.source, "unknown policy %q", rule.Target)
+			continue
+		}
+		if hasMatch {
+			b.report.skip("rule", pending.source, "unreachable after the final rule")
+			continue
+		}
+		hasMatch = rule.Type == "MATCH"
+		cfg.Rules = append(cfg.Rules, replaceTarget(pending.rule, rule.Target, target))
+	}
+	if !hasMatch {
+		cfg.Rules = append(cfg.Rules, "MATCH,"+cfg.ProxyGroups[0].Name)
+	}
+
+	b.report.Proxies = len(cfg.Proxies)
+	b.report.Groups = len(cfg.ProxyGroups)
+	b.report.Rules = len(cfg.Rules)
+	return cfg, b.report, nil
+}
+
+// replaceTarget 替换规则的目标（目标之后可能还有 no-resolve 等参数）
+func replaceTarget(rule, from, to string) string {
+	if from == to {
+		return rule
+	}
+	fields := strings.Split(rule, ",")
+	for i := len(fields) - 1; i >= 0; i-- {
+		if strings.TrimSpace(fields[i]) == from {
+			fields[i] = to
+			break
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// options 键值形式的选项（Surge、Quantumult X 的 key=value），记录已读取的键以便报告未转换的选项
+type options struct {
+	values map[string]string
+	keys   []string
+	used   map[string]bool
+}
+
+// parseOptions 解析 key=value 列表，不含 '=' 的项按顺序返回
+func parseOptions(items []string) (*options, []string) {
+	opts := &options{values: make(map[string]string), used: make(map[string]bool)}
+	var positional []string
+	for _, item := range items {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			positional = append(positional, strings.TrimSpace(item))
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if _, dup := opts.values[key]; !dup {
+			opts.keys = append(opts.keys, key)
+		}
+		opts.values[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return opts, positional
+}
+
+// get 读取选项
+func (o *options) get(key string) string {
+	o.used[key] = true
+	return o.values[key]
+}
+
+// bool 读取布尔选项
+func (o *options) bool(key string) bool {
+	return isTrue(o.get(key))
+}
+
+// ignore 标记不影响转换结果的选项
+func (o *options) ignore(keys ...string) {
+	for _, key := range keys {
+		o.used[key] = true
+	}
+}
+
+// unused 未读取的选项
+func (o *options) unused() []string {
+	var keys []string
+	for _, key := range o.keys {
+		if !o.used[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// reportUnused 报告未转换的选项
+func (b *builder) reportUnused(section, item string, opts *options) {
+	if unused := opts.unused(); len(unused) > 0 {
+		b.report.skip(section, item, "options not converted: %s", strings.Join(unused, ", "))
+	}
+}
+
+// splitList 按逗号拆分并去除空白
+func splitList(s string) []string {
+	parts := strings.Split(s, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// isTrue 判断选项值是否表示 true
+func isTrue(value string) bool {
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+// setTLSName 设置 TLS 服务器名：trojan/hysteria2 使用 sni，其余使用 servername
+func setTLSName(proxy *config.Proxy, name string) {
+	switch proxy.Type {
+	case "trojan", "hysteria2":
+		proxy.SNI = name
+	default:
+		proxy.ServerName = name
+	}
+}
+
+// setWebSocket 设置 ws 传输
+func setWebSocket(proxy *config.Proxy, path, host string) {
+	proxy.Network = "ws"
+	proxy.WSOpts = &config.WSOptions{Path: path}
+	if host != "" {
+		proxy.WSOpts.Headers = map[string]string{"Host": host}
+	}
+}
+
+// section INI 风格配置中的段落
+type section struct {
+	name  string
+	lines []string
+}
+
+// parseSections 解析 [Section] 风格的配置，跳过空行和注释（#、;、//）
+func parseSections(data []byte) []*section {
+	var sections []*section
+	current := &section{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = &section{name: strings.TrimSpace(line[1 : len(line)-1])}
+			sections = append(sections, current)
+			continue
+		}
+		current.lines = append(current.lines, line)
+	}
+	return sections
+}
+
+// skipSection 报告整段未导入的内容
+func (b *builder) skipSection(s *section) {
+	if len(s.lines) > 0 {
+		b.report.skip(s.name, fmt.Sprintf("%d line(s)", len(s.lines)), "section not imported")
+	}
+}
@@ -0,0 +1,167 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// update 重新生成 testdata 中的期望输出：go test ./internal/convert -update
+var update = flag.Bool("update", false, "update golden files")
+
+// importResult 期望输出中比较的部分，其余设置来自默认配置
+type importResult struct {
+	Proxies     []config.Proxy      `yaml:"proxies"`
+	ProxyGroups []config.ProxyGroup `yaml:"proxy-groups"`
+	Rules       []string            `yaml:"rules"`
+}
+
+func TestImportGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{"surge", FormatSurge, "surge.conf"},
+		{"singbox", FormatSingBox, "singbox.json"},
+		{"qx", FormatQuantumultX, "qx.conf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, report, err := Import(tt.format, data)
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			if err := config.Validate(cfg); err != nil {
+				t.Errorf("imported config is invalid: %v", err)
+			}
+
+			out, err := yaml.Marshal(importResult{cfg.Proxies, cfg.ProxyGroups, cfg.Rules})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+".golden.yaml", out)
+
+			out, err = json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+".report.golden.json", append(out, '\n'))
+		})
+	}
+}
+
+// checkGolden 与 testdata 中的期望输出比较，-update 时改为写入
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch (run with -update to accept)\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	tests := []struct {
+		name        string
+		groups      []config.ProxyGroup
+		rules       []string
+		wantGroups  []config.ProxyGroup
+		wantRules   []string
+		wantSkipped []Skipped
+	}{
+		{
+			name:       "default group and final rule",
+			rules:      []string{"DOMAIN,a.example,HK"},
+			wantGroups: []config.ProxyGroup{{Name: "PROXY", Type: "select", Proxies: []string{"HK", "DIRECT"}}},
+			wantRules:  []string{"DOMAIN,a.example,HK", "MATCH,PROXY"},
+		},
+		{
+			name:   "rules after the first MATCH are dropped",
+			groups: []config.ProxyGroup{{Name: "Proxy", Type: "select", Proxies: []string{"HK"}}},
+			rules:  []string{"MATCH,Proxy", "DOMAIN,late.example,HK", "MATCH,DIRECT"},
+			wantGroups: []config.ProxyGroup{
+				{Name: "Proxy", Type: "select", Proxies: []string{"HK"}},
+			},
+			wantRules: []string{"MATCH,Proxy"},
+			wantSkipped: []Skipped{
+				{Section: "rule", Item: "DOMAIN,late.example,HK", Reason: "unreachable after the final rule"},
+				{Section: "rule", Item: "MATCH,DIRECT", Reason: "unreachable after the final rule"},
+			},
+		},
+		{
+			name: "unknown members and targets",
+			groups: []config.ProxyGroup{
+				{Name: "Proxy", Type: "select", Proxies: []string{"HK", "Missing", "direct"}},
+				{Name: "Empty", Type: "select", Proxies: []string{"Missing"}},
+			},
+			rules: []string{"DOMAIN,a.example,Nowhere", "IP-CIDR,10.0.0.0/8,reject,no-resolve", "MATCH,Missing"},
+			wantGroups: []config.ProxyGroup{
+				{Name: "Proxy", Type: "select", Proxies: []string{"HK", "DIRECT"}},
+				{Name: "Empty", Type: "select", Proxies: []string{"DIRECT"}},
+			},
+			wantRules: []string{"IP-CIDR,10.0.0.0/8,REJECT,no-resolve", "MATCH,Proxy"},
+			wantSkipped: []Skipped{
+				{Section: "group", Item: "Proxy", Reason: `unknown member "Missing" removed`},
+				{Section: "group", Item: "Empty", Reason: `unknown member "Missing" removed`},
+				{Section: "group", Item: "Empty", Reason: "no usable members, DIRECT added"},
+				{Section: "rule", Item: "DOMAIN,a.example,Nowhere", Reason: `unknown policy "Nowhere"`},
+				{Section: "rule", Item: "MATCH,Missing", Reason: `unknown policy "Missing"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuilder()
+			b.addProxy(&config.Proxy{Name: "HK", Type: "ss", Server: "hk.example.com", Port: 443, Cipher: "aes-128-gcm", Password: "x"}, "HK")
+			for i := range tt.groups {
+				b.addGroup(&tt.groups[i], tt.groups[i].Name)
+			}
+			for _, rule := range tt.rules {
+				b.addRule(rule, rule)
+			}
+
+			cfg, report, err := b.finish()
+			if err != nil {
+				t.Fatalf("finish: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.ProxyGroups, tt.wantGroups) {
+				t.Errorf("groups = %+v, want %+v", cfg.ProxyGroups, tt.wantGroups)
+			}
+			if !reflect.DeepEqual(cfg.Rules, tt.wantRules) {
+				t.Errorf("rules = %v, want %v", cfg.Rules, tt.wantRules)
+			}
+			if !reflect.DeepEqual(report.Skipped, tt.wantSkipped) {
+				t.Errorf("skipped = %+v, want %+v", report.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestFinishNothingToImport(t *testing.T) {
+	if _, _, err := newBuilder().finish(); err == nil {
+		t.Fatal("expected an error without proxies or groups")
+	}
+}
@@ -0,0 +1,284 @@
+package convert
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+// qxProxyPolicy Quantumult X 的内置 proxy 策略（界面中选择的节点），转换为包含所有节点的选择组
+const qxProxyPolicy = "PROXY"
+
+// qxPolicies Quantumult X 内置策略对应的 mihomo 出站
+var qxPolicies = map[string]string{
+	"direct":       "DIRECT",
+	"reject":       "REJECT",
+	"reject-200":   "REJECT",
+	"reject-img":   "REJECT",
+	"reject-dict":  "REJECT",
+	"reject-array": "REJECT",
+	"reject-drop":  "REJECT-DROP",
+	"proxy":        qxProxyPolicy,
+}
+
+// qxRuleTypes Quantumult X 分流类型对应的 mihomo 规则类型
+var qxRuleTypes = map[string]string{
+	"host":          "DOMAIN",
+	"host-suffix":   "DOMAIN-SUFFIX",
+	"host-keyword":  "DOMAIN-KEYWORD",
+	"host-wildcard": "DOMAIN-WILDCARD",
+	"ip-cidr":       "IP-CIDR",
+	"ip6-cidr":      "IP-CIDR6",
+	"geoip":         "GEOIP",
+	"ip-asn":        "IP-ASN",
+	"final":         "MATCH",
+}
+
+// qxGroupTypes Quantumult X 策略类型对应的 mihomo 代理组类型
+var qxGroupTypes = map[string]string{
+	"static":                "select",
+	"url-latency-benchmark": "url-test",
+	"available":             "fallback",
+	"round-robin":           "load-balance",
+	"dest-hash":             "load-balance",
+}
+
+// importQuantumultX 转换 Quantumult X 配置的 [server_local]、[policy] 和 [filter_local] 段落
+func importQuantumultX(b *builder, data []byte) error {
+	sections := parseSections(data)
+	if len(sections) == 0 {
+		return fmt.Errorf("not a Quantumult X config: no [server_local] or [filter_local] section found")
+	}
+
+	for name, target := range qxPolicies {
+		b.alias(name, target)
+	}
+
+	usesProxy := false
+	for _, s := range sections {
+		switch strings.ToLower(s.name) {
+		case "server_local":
+			for _, line := range s.lines {
+				b.qxServer(line)
+			}
+		case "policy":
+			for _, line := range s.lines {
+				usesProxy = b.qxPolicy(line) || usesProxy
+			}
+		case "filter_local":
+			for _, line := range s.lines {
+				usesProxy = b.qxFilter(line) || usesProxy
+			}
+		default:
+			b.skipSection(s)
+		}
+	}
+
+	// 内置 proxy 策略被引用且没有同名策略时，生成包含所有节点的选择组
+	if usesProxy && !b.names[qxProxyPolicy] {
+		members := make([]string, 0, len(b.config.Proxies)+1)
+		for _, p := range b.config.Proxies {
+			members = append(members, p.Name)
+		}
+		members = append(members, "DIRECT")
+		b.addGroup(&config.ProxyGroup{Name: qxProxyPolicy, Type: "select", Proxies: members}, "proxy")
+	}
+	return nil
+}
+
+// qxServer 转换 [server_local] 中的一行，如 "trojan=example.com:443, password=x, over-tls=true, tag=HK"
+func (b *builder) qxServer(line string) {
+	proxyType, value, ok := strings.Cut(line, "=")
+	if !ok {
+		b.report.skip("proxy", line, "not a 'type=host:port, ...' line")
+		return
+	}
+	proxyType = strings.ToLower(strings.TrimSpace(proxyType))
+	items := splitList(value)
+	opts, _ := parseOptions(items[1:])
+
+	name := opts.get("tag")
+	if name == "" {
+		name = items[0]
+	}
+	host, portText, err := net.SplitHostPort(items[0])
+	port, perr := strconv.Atoi(portText)
+	if err != nil || perr != nil {
+		b.report.skip("proxy", name, "invalid server address %q", items[0])
+		return
+	}
+	proxy := &config.Proxy{Name: name, Server: host, Port: port}
+	opts.ignore("fast-open", "tls13", "aead", "server_check_url")
+
+	obfs := strings.ToLower(opts.get("obfs"))
+	obfsHost, obfsURI := opts.get("obfs-host"), opts.get("obfs-uri")
+
+	switch proxyType {
+	case "shadowsocks":
+		proxy.Type = "ss"
+		proxy.Cipher = opts.get("method")
+		proxy.Password = opts.get("password")
+		switch obfs {
+		case "":
+		case "http", "tls":
+			proxy.Plugin = "obfs"
+			proxy.PluginOpts = map[string]interface{}{"mode": obfs}
+			if obfsHost != "" {
+				proxy.PluginOpts["host"] = obfsHost
+			}
+		case "ws", "wss":
+			proxy.Plugin = "v2ray-plugin"
+			proxy.PluginOpts = map[string]interface{}{"mode": "websocket", "host": obfsHost, "path": obfsURI}
+			if obfs == "wss" {
+				proxy.PluginOpts["tls"] = true
+			}
+		default:
+			b.report.skip("proxy", name, "unsupported obfs %q", obfs)
+			return
+		}
+	case "vmess", "vless", "trojan":
+		proxy.Type = proxyType
+		if proxyType == "trojan" {
+			proxy.Password = opts.get("password")
+			proxy.TLS = true
+		} else {
+			proxy.UUID = opts.get("password")
+		}
+		if proxyType == "vmess" {
+			proxy.Cipher = opts.get("method")
+			if proxy.Cipher == "" || proxy.Cipher == "chacha20-ietf-poly1305" {
+				proxy.Cipher = "auto"
+			}
+		} else {
+			opts.ignore("method")
+		}
+		proxy.Flow = opts.get("vless-flow")
+		if key := opts.get("reality-base64-pubkey"); key != "" {
+			proxy.TLS = true
+			proxy.RealityOpts = &config.RealityOptions{PublicKey: key, ShortID: opts.get("reality-hex-shortid")}
+		}
+
+		switch obfs {
+		case "":
+		case "over-tls":
+			proxy.TLS = true
+		case "ws", "wss":
+			setWebSocket(proxy, obfsURI, obfsHost)
+			proxy.TLS = proxy.TLS || obfs == "wss"
+		default:
+			b.report.skip("proxy", name, "unsupported obfs %q", obfs)
+			return
+		}
+		if proxy.TLS && obfsHost != "" {
+			setTLSName(proxy, obfsHost)
+		}
+	case "http", "socks5":
+		proxy.Type = proxyType
+		proxy.Username, proxy.Password = opts.get("username"), opts.get("password")
+	default:
+		b.report.skip("proxy", name, "unsupported server type %q", proxyType)
+		return
+	}
+
+	proxy.UDP = opts.bool("udp-relay")
+	if opts.bool("over-tls") {
+		proxy.TLS = true
+	}
+	if sni := opts.get("tls-host"); sni != "" {
+		setTLSName(proxy, sni)
+	}
+	if v := opts.get("tls-verification"); v != "" {
+		proxy.SkipCertVerify = !isTrue(v)
+	}
+
+	b.reportUnused("proxy", name, opts)
+	b.addProxy(proxy, name)
+}
+
+// qxPolicy 转换 [policy] 中的一行，如 "static=Proxy, HK, JP, direct, img-url=..."；
+// 返回是否引用了内置 proxy 策略
+func (b *builder) qxPolicy(line string) bool {
+	policyType, value, ok := strings.Cut(line, "=")
+	if !ok {
+		b.report.skip("group", line, "not a 'type=name, ...' line")
+		return false
+	}
+	policyType = strings.ToLower(strings.TrimSpace(policyType))
+	opts, positional := parseOptions(splitList(value))
+	if len(positional) == 0 {
+		b.report.skip("group", line, "missing policy name")
+		return false
+	}
+	name := positional[0]
+
+	groupType, ok := qxGroupTypes[policyType]
+	if !ok {
+		b.report.skip("group", name, "unsupported policy type %q", policyType)
+		return false
+	}
+	group := &config.ProxyGroup{Name: name, Type: groupType, Proxies: positional[1:]}
+	group.URL = opts.get("server-check-url")
+	group.Interval, _ = strconv.Atoi(opts.get("check-interval"))
+	group.Tolerance, _ = strconv.Atoi(opts.get("tolerance"))
+	opts.ignore("img-url", "alive-checking")
+
+	b.reportUnused("group", name, opts)
+	b.addGroup(group, name)
+	return containsFold(group.Proxies, "proxy")
+}
+
+// qxFilter 转换 [filter_local] 中的一行，如 "host-suffix, google.com, proxy"；
+// 返回是否引用了内置 proxy 策略
+func (b *builder) qxFilter(line string) bool {
+	fields := splitList(line)
+	ruleType, ok := qxRuleTypes[strings.ToLower(fields[0])]
+	if !ok {
+		b.report.skip("rule", line, "unsupported filter type %s", fields[0])
+		return false
+	}
+
+	var payload string
+	if ruleType != "MATCH" && len(fields) > 1 {
+		payload, fields = fields[1], fields[1:]
+	}
+	if len(fields) < 2 || fields[1] == "" {
+		b.report.skip("rule", line, "missing policy")
+		return false
+	}
+	target, params := fields[1], fields[2:]
+
+	rule := ruleType
+	if payload != "" {
+		if ruleType == "GEOIP" {
+			payload = strings.ToUpper(payload)
+		}
+		rule += "," + payload
+	}
+	rule += "," + target
+	var dropped []string
+	for _, param := range params {
+		if strings.EqualFold(param, "no-resolve") {
+			rule += ",no-resolve"
+		} else {
+			dropped = append(dropped, param)
+		}
+	}
+	if len(dropped) > 0 {
+		b.report.skip("rule", line, "options not converted: %s", strings.Join(dropped, ", "))
+	}
+	b.addRule(rule, line)
+	return strings.EqualFold(target, "proxy")
+}
+
+// containsFold 检查列表中是否包含指定字符串（不区分大小写）
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
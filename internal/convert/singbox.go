@@ -0,0 +1,503 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+// object sing-box JSON 对象，记录已读取的字段以便报告未转换的选项
+type object struct {
+	values map[string]interface{}
+	used   map[string]bool
+	prefix string // 子对象在报告中的字段前缀，如 "tls."
+}
+
+func newObject(value interface{}) *object {
+	m, _ := value.(map[string]interface{})
+	return &object{values: m, used: make(map[string]bool)}
+}
+
+// has 检查字段是否存在
+func (o *object) has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+// str 读取字符串字段
+func (o *object) str(key string) string {
+	o.used[key] = true
+	s, _ := o.values[key].(string)
+	return s
+}
+
+// int 读取数字字段
+func (o *object) int(key string) int {
+	o.used[key] = true
+	n, _ := o.values[key].(float64)
+	return int(n)
+}
+
+// bool 读取布尔字段
+func (o *object) bool(key string) bool {
+	o.used[key] = true
+	b, _ := o.values[key].(bool)
+	return b
+}
+
+// strings 读取字符串列表字段，单个字符串视为只有一项的列表
+func (o *object) strings(key string) []string {
+	o.used[key] = true
+	switch v := o.values[key].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item := item.(type) {
+			case string:
+				list = append(list, item)
+			case float64:
+				list = append(list, strconv.Itoa(int(item)))
+			}
+		}
+		return list
+	case float64:
+		return []string{strconv.Itoa(int(v))}
+	}
+	return nil
+}
+
+// object 读取子对象；字段不存在时返回空对象
+func (o *object) object(key string) *object {
+	o.used[key] = true
+	child := newObject(o.values[key])
+	child.prefix = o.prefix + key + "."
+	return child
+}
+
+// ignore 标记不影响转换结果的字段
+func (o *object) ignore(keys ...string) {
+	for _, key := range keys {
+		o.used[key] = true
+	}
+}
+
+// unused 未读取的字段（排序）
+func (o *object) unused() []string {
+	var keys []string
+	for key := range o.values {
+		if !o.used[key] {
+			keys = append(keys, o.prefix+key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reportUnusedFields 报告对象及其子对象中未转换的字段
+func (b *builder) reportUnusedFields(section, item string, fields ...*object) {
+	var unused []string
+	for _, o := range fields {
+		unused = append(unused, o.unused()...)
+	}
+	if len(unused) > 0 {
+		b.report.skip(section, item, "options not converted: %s", strings.Join(unused, ", "))
+	}
+}
+
+// importSingBox 转换 sing-box 配置的 outbounds 和 route
+func importSingBox(b *builder, data []byte) error {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("not a sing-box config: %w", err)
+	}
+	outbounds, ok := root["outbounds"].([]interface{})
+	if !ok {
+		return fmt.Errorf("not a sing-box config: no outbounds found")
+	}
+
+	keys := make([]string, 0, len(root))
+	for key := range root {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key != "outbounds" && key != "route" {
+			b.report.skip(key, "", "section not imported")
+		}
+	}
+
+	var first string
+	for _, value := range outbounds {
+		out := newObject(value)
+		tag := out.str("tag")
+		if first == "" {
+			first = tag
+		}
+		b.singBoxOutbound(out, tag)
+	}
+
+	route := newObject(root["route"])
+	rules, _ := route.values["rules"].([]interface{})
+	route.ignore("rules")
+	for _, value := range rules {
+		b.singBoxRule(newObject(value))
+	}
+	if route.has("rule_set") {
+		b.report.skip("route", "rule_set", "rule sets are not imported")
+	}
+	route.ignore("rule_set", "auto_detect_interface", "default_mark", "default_interface")
+
+	// 未设置 final 时 sing-box 使用第一个出站
+	final := route.str("final")
+	if final == "" {
+		final = first
+	}
+	if final != "" {
+		b.addRule("MATCH,"+final, "final: "+final)
+	}
+	b.reportUnusedFields("route", "", route)
+	return nil
+}
+
+// singBoxOutbound 转换一个出站
+func (b *builder) singBoxOutbound(out *object, tag string) {
+	outType := out.str("type")
+	switch outType {
+	case "direct":
+		b.alias(tag, "DIRECT")
+		return
+	case "block":
+		b.alias(tag, "REJECT")
+		return
+	case "dns":
+		b.report.skip("proxy", tag, "dns outbound has no mihomo equivalent")
+		return
+	case "selector", "urltest":
+		b.singBoxGroup(out, tag, outType)
+		return
+	}
+
+	proxy := &config.Proxy{Name: tag, Server: out.str("server"), Port: out.int("server_port"), UDP: true}
+	switch outType {
+	case "shadowsocks":
+		proxy.Type = "ss"
+		proxy.Cipher = out.str("method")
+		proxy.Password = out.str("password")
+		if plugin := out.str("plugin"); plugin != "" {
+			if !singBoxPlugin(proxy, plugin, out.str("plugin_opts")) {
+				b.report.skip("proxy", tag, "unsupported plugin %q", plugin)
+				return
+			}
+		}
+	case "vmess":
+		proxy.Type = "vmess"
+		proxy.UUID = out.str("uuid")
+		proxy.AlterID = out.int("alter_id")
+		proxy.Cipher = out.str("security")
+		if proxy.Cipher == "" {
+			proxy.Cipher = "auto"
+		}
+	case "vless":
+		proxy.Type = "vless"
+		proxy.UUID = out.str("uuid")
+		proxy.Flow = out.str("flow")
+	case "trojan":
+		proxy.Type = "trojan"
+		proxy.Password = out.str("password")
+	case "hysteria2":
+		proxy.Type = "hysteria2"
+		proxy.Password = out.str("password")
+		obfs := out.object("obfs")
+		proxy.Obfs, proxy.ObfsPassword = obfs.str("type"), obfs.str("password")
+		if up := out.int("up_mbps"); up > 0 {
+			proxy.Up = fmt.Sprintf("%d Mbps", up)
+		}
+		if down := out.int("down_mbps"); down > 0 {
+			proxy.Down = fmt.Sprintf("%d Mbps", down)
+		}
+	case "http", "socks":
+		proxy.Type = outType
+		if outType == "socks" {
+			proxy.Type = "socks5"
+			out.ignore("version")
+		}
+		proxy.Username, proxy.Password = out.str("username"), out.str("password")
+		proxy.UDP = proxy.Type == "socks5"
+	default:
+		b.report.skip("proxy", tag, "unsupported outbound type %q", outType)
+		return
+	}
+
+	if network := out.str("network"); network == "tcp" {
+		proxy.UDP = false
+	}
+	out.ignore("domain_strategy", "tcp_fast_open", "udp_fragment", "connect_timeout", "packet_encoding")
+
+	tls := out.object("tls")
+	utls, reality := tls.object("utls"), tls.object("reality")
+	if tls.bool("enabled") {
+		proxy.TLS = proxy.Type != "hysteria2"
+		if sni := tls.str("server_name"); sni != "" {
+			setTLSName(proxy, sni)
+		}
+		proxy.SkipCertVerify = tls.bool("insecure")
+		proxy.ALPN = tls.strings("alpn")
+		if utls.bool("enabled") {
+			proxy.ClientFingerprint = utls.str("fingerprint")
+		}
+		if reality.bool("enabled") {
+			proxy.RealityOpts = &config.RealityOptions{PublicKey: reality.str("public_key"), ShortID: reality.str("short_id")}
+		}
+	}
+
+	transport := out.object("transport")
+	switch transportType := transport.str("type"); transportType {
+	case "":
+	case "ws":
+		setWebSocket(proxy, transport.str("path"), transport.object("headers").str("Host"))
+		transport.ignore("max_early_data", "early_data_header_name")
+	case "grpc":
+		proxy.Network = "grpc"
+		proxy.GRPCOpts = &config.GRPCOptions{GRPCServiceName: transport.str("service_name")}
+	case "http":
+		proxy.Network = "h2"
+		transport.ignore("host", "path")
+	default:
+		b.report.skip("proxy", tag, "unsupported transport %q", transportType)
+		return
+	}
+
+	b.reportUnusedFields("proxy", tag, out, tls, utls, reality, transport)
+	b.addProxy(proxy, tag)
+}
+
+// singBoxPlugin 转换 shadowsocks 插件（obfs-local、v2ray-plugin），插件参数为 SIP003 格式
+func singBoxPlugin(proxy *config.Proxy, plugin, pluginOpts string) bool {
+	opts := make(map[string]interface{})
+	for _, opt := range strings.Split(pluginOpts, ";") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch {
+		case plugin == "obfs-local" && key == "obfs":
+			opts["mode"] = value
+		case plugin == "obfs-local" && key == "obfs-host":
+			opts["host"] = value
+		case plugin == "v2ray-plugin" && (key == "mode" || key == "host" || key == "path"):
+			opts[key] = value
+		case plugin == "v2ray-plugin" && key == "tls":
+			opts["tls"] = true
+		}
+	}
+
+	switch plugin {
+	case "obfs-local":
+		proxy.Plugin = "obfs"
+	case "v2ray-plugin":
+		proxy.Plugin = "v2ray-plugin"
+		if _, ok := opts["mode"]; !ok {
+			opts["mode"] = "websocket"
+		}
+	default:
+		return false
+	}
+	proxy.PluginOpts = opts
+	return true
+}
+
+// singBoxGroup 转换 selector/urltest 出站
+func (b *builder) singBoxGroup(out *object, tag, outType string) {
+	group := &config.ProxyGroup{Name: tag, Type: "select", Proxies: out.strings("outbounds")}
+	if outType == "urltest" {
+		group.Type = "url-test"
+		group.URL = out.str("url")
+		if interval, err := time.ParseDuration(out.str("interval")); err == nil {
+			group.Interval = int(interval.Seconds())
+		}
+		group.Tolerance = out.int("tolerance")
+	} else if def := out.str("default"); def != "" {
+		// mihomo 选择组默认使用第一个成员
+		members := []string{def}
+		for _, name := range group.Proxies {
+			if name != def {
+				members = append(members, name)
+			}
+		}
+		group.Proxies = members
+	}
+	out.ignore("interrupt_exist_connections", "idle_timeout")
+
+	b.reportUnusedFields("group", tag, out)
+	b.addGroup(group, tag)
+}
+
+// singBoxConditions sing-box 路由规则的匹配字段。同一类别内的条件为或，不同类别之间为与；
+// 目标地址相关的字段（域名、IP、geoip/geosite）属于同一类别
+var singBoxConditions = []struct {
+	field    string
+	category string
+	ruleType string
+}{
+	{"domain", "destination", "DOMAIN"},
+	{"domain_suffix", "destination", "DOMAIN-SUFFIX"},
+	{"domain_keyword", "destination", "DOMAIN-KEYWORD"},
+	{"domain_regex", "destination", "DOMAIN-REGEX"},
+	{"geosite", "destination", "GEOSITE"},
+	{"geoip", "destination", "GEOIP"},
+	{"ip_cidr", "destination", "IP-CIDR"},
+	{"ip_is_private", "destination", "GEOIP"},
+	{"source_ip_cidr", "source", "SRC-IP-CIDR"},
+	{"port", "port", "DST-PORT"},
+	{"port_range", "port", "DST-PORT"},
+	{"source_port", "source_port", "SRC-PORT"},
+	{"source_port_range", "source_port", "SRC-PORT"},
+	{"process_name", "process_name", "PROCESS-NAME"},
+	{"process_path", "process_path", "PROCESS-PATH"},
+	{"network", "network", "NETWORK"},
+}
+
+// singBoxRule 转换一条路由规则。只有一类条件时每个值生成一条规则，其余情况生成逻辑规则
+func (b *builder) singBoxRule(rule *object) {
+	source, _ := json.Marshal(rule.values)
+
+	var target string
+	switch action := rule.str("action"); action {
+	case "", "route":
+		target = rule.str("outbound")
+	case "reject":
+		target = "REJECT"
+		rule.ignore("method", "no_drop")
+	default:
+		b.report.skip("rule", string(source), "action %q has no mihomo equivalent", action)
+		return
+	}
+
+	expr, values, err := singBoxMatch(rule)
+	if err != nil {
+		b.report.skip("rule", string(source), "%v", err)
+		return
+	}
+	if values == nil {
+		values = []string{expr}
+	}
+	for _, value := range values {
+		b.addRule(value+","+target, string(source))
+	}
+}
+
+// singBoxMatch 将规则的匹配条件转换为 mihomo 条件表达式（不含目标）。
+// 只有一类条件且不取反时同时返回每个值单独的条件，便于拆成多条普通规则。
+func singBoxMatch(rule *object) (string, []string, error) {
+	var parts []string
+	var values []string
+
+	if rule.str("type") == "logical" {
+		mode := strings.ToUpper(rule.str("mode"))
+		if mode != "AND" && mode != "OR" {
+			return "", nil, fmt.Errorf("unsupported logical mode %q", mode)
+		}
+		subs, _ := rule.values["rules"].([]interface{})
+		rule.ignore("rules")
+		for _, sub := range subs {
+			expr, _, err := singBoxMatch(newObject(sub))
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, "("+expr+")")
+		}
+		if len(parts) == 0 {
+			return "", nil, fmt.Errorf("logical rule has no sub-rules")
+		}
+		expr := mode + ",(" + strings.Join(parts, ",") + ")"
+		if rule.bool("invert") {
+			expr = "NOT,((" + expr + "))"
+		}
+		if unused := rule.unused(); len(unused) > 0 {
+			return "", nil, fmt.Errorf("unsupported conditions: %s", strings.Join(unused, ", "))
+		}
+		return expr, nil, nil
+	}
+
+	var categories []string
+	conditions := make(map[string][]string)
+	for _, c := range singBoxConditions {
+		if !rule.has(c.field) {
+			continue
+		}
+		var fieldValues []string
+		switch c.field {
+		case "ip_is_private":
+			if rule.bool(c.field) {
+				fieldValues = []string{"PRIVATE"}
+			}
+		case "port_range", "source_port_range":
+			for _, r := range rule.strings(c.field) {
+				fieldValues = append(fieldValues, strings.Replace(r, ":", "-", 1))
+			}
+		case "domain_suffix":
+			for _, s := range rule.strings(c.field) {
+				fieldValues = append(fieldValues, strings.TrimPrefix(s, "."))
+			}
+		case "geoip":
+			for _, s := range rule.strings(c.field) {
+				fieldValues = append(fieldValues, strings.ToUpper(s))
+			}
+		default:
+			fieldValues = rule.strings(c.field)
+		}
+
+		if _, ok := conditions[c.category]; !ok {
+			categories = append(categories, c.category)
+		}
+		for _, value := range fieldValues {
+			ruleType := c.ruleType
+			if c.field == "ip_cidr" {
+				if prefix, err := netip.ParsePrefix(value); err == nil && prefix.Addr().Is6() {
+					ruleType = "IP-CIDR6"
+				}
+			}
+			conditions[c.category] = append(conditions[c.category], ruleType+","+value)
+		}
+	}
+
+	invert := rule.bool("invert")
+	if unused := rule.unused(); len(unused) > 0 {
+		// 忽略未知条件会扩大匹配范围，整条规则跳过
+		return "", nil, fmt.Errorf("unsupported conditions: %s", strings.Join(unused, ", "))
+	}
+	if len(categories) == 0 {
+		return "", nil, fmt.Errorf("rule has no conditions")
+	}
+
+	// 同一类别内为或，不同类别之间为与
+	for _, category := range categories {
+		list := conditions[category]
+		if len(list) == 1 {
+			parts = append(parts, "("+list[0]+")")
+			continue
+		}
+		sub := make([]string, len(list))
+		for i, v := range list {
+			sub[i] = "(" + v + ")"
+		}
+		parts = append(parts, "(OR,("+strings.Join(sub, ",")+"))")
+	}
+	if len(categories) == 1 && !invert {
+		values = conditions[categories[0]]
+	}
+
+	expr := "AND,(" + strings.Join(parts, ",") + ")"
+	if len(parts) == 1 {
+		expr = parts[0][1 : len(parts[0])-1]
+	}
+	if invert {
+		expr = "NOT,((" + expr + "))"
+	}
+	return expr, values, nil
+}
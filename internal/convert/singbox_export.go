@@ -0,0 +1,771 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+const (
+	// sbDirectTag 直连出站
+	sbDirectTag = "DIRECT"
+
+	// sbBlockTag 拒绝出站，仅在代理组或 final 引用 REJECT 时生成（其余规则使用 reject 动作）
+	sbBlockTag = "REJECT"
+
+	// sbTUNAddress4、sbTUNAddress6 TUN 接口地址（mihomo 配置中没有对应字段，使用 sing-box 文档中的默认值）
+	sbTUNAddress4 = "172.19.0.1/30"
+	sbTUNAddress6 = "fdfe:dcba:9876::1/126"
+)
+
+type sbConfig struct {
+	Log          sbLog           `json:"log"`
+	DNS          *sbDNS          `json:"dns,omitempty"`
+	Inbounds     []sbInbound     `json:"inbounds,omitempty"`
+	Outbounds    []sbOutbound    `json:"outbounds"`
+	Route        sbRoute         `json:"route"`
+	Experimental *sbExperimental `json:"experimental,omitempty"`
+}
+
+type sbLog struct {
+	Disabled bool   `json:"disabled,omitempty"`
+	Level    string `json:"level,omitempty"`
+}
+
+type sbDNS struct {
+	Servers []sbDNSServer `json:"servers"`
+	Rules   []sbDNSRule   `json:"rules,omitempty"`
+	Final   string        `json:"final,omitempty"`
+	FakeIP  *sbFakeIP     `json:"fakeip,omitempty"`
+}
+
+type sbDNSServer struct {
+	Tag             string `json:"tag"`
+	Address         string `json:"address"`
+	AddressResolver string `json:"address_resolver,omitempty"`
+	Detour          string `json:"detour,omitempty"`
+}
+
+type sbDNSRule struct {
+	Domain       []string `json:"domain,omitempty"`
+	DomainSuffix []string `json:"domain_suffix,omitempty"`
+	DomainRegex  []string `json:"domain_regex,omitempty"`
+	QueryType    []string `json:"query_type,omitempty"`
+	Server       string   `json:"server"`
+}
+
+type sbFakeIP struct {
+	Enabled    bool   `json:"enabled"`
+	Inet4Range string `json:"inet4_range"`
+}
+
+type sbInbound struct {
+	Type                string   `json:"type"`
+	Tag                 string   `json:"tag"`
+	Listen              string   `json:"listen,omitempty"`
+	ListenPort          int      `json:"listen_port,omitempty"`
+	Address             []string `json:"address,omitempty"`
+	AutoRoute           bool     `json:"auto_route,omitempty"`
+	StrictRoute         bool     `json:"strict_route,omitempty"`
+	Stack               string   `json:"stack,omitempty"`
+	RouteExcludeAddress []string `json:"route_exclude_address,omitempty"`
+}
+
+type sbOutbound struct {
+	Type       string       `json:"type"`
+	Tag        string       `json:"tag"`
+	Outbounds  []string     `json:"outbounds,omitempty"`
+	URL        string       `json:"url,omitempty"`
+	Interval   string       `json:"interval,omitempty"`
+	Tolerance  int          `json:"tolerance,omitempty"`
+	Server     string       `json:"server,omitempty"`
+	ServerPort int          `json:"server_port,omitempty"`
+	Method     string       `json:"method,omitempty"`
+	Username   string       `json:"username,omitempty"`
+	Password   string       `json:"password,omitempty"`
+	UUID       string       `json:"uuid,omitempty"`
+	AlterID    int          `json:"alter_id,omitempty"`
+	Security   string       `json:"security,omitempty"`
+	Flow       string       `json:"flow,omitempty"`
+	Network    string       `json:"network,omitempty"`
+	Plugin     string       `json:"plugin,omitempty"`
+	PluginOpts string       `json:"plugin_opts,omitempty"`
+	UpMbps     int          `json:"up_mbps,omitempty"`
+	DownMbps   int          `json:"down_mbps,omitempty"`
+	Obfs       *sbObfs      `json:"obfs,omitempty"`
+	TLS        *sbTLS       `json:"tls,omitempty"`
+	Transport  *sbTransport `json:"transport,omitempty"`
+}
+
+type sbObfs struct {
+	Type     string `json:"type"`
+	Password string `json:"password,omitempty"`
+}
+
+type sbTLS struct {
+	Enabled    bool       `json:"enabled"`
+	ServerName string     `json:"server_name,omitempty"`
+	Insecure   bool       `json:"insecure,omitempty"`
+	ALPN       []string   `json:"alpn,omitempty"`
+	UTLS       *sbUTLS    `json:"utls,omitempty"`
+	Reality    *sbReality `json:"reality,omitempty"`
+}
+
+type sbUTLS struct {
+	Enabled     bool   `json:"enabled"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+type sbReality struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey string `json:"public_key"`
+	ShortID   string `json:"short_id,omitempty"`
+}
+
+type sbTransport struct {
+	Type        string            `json:"type"`
+	Path        string            `json:"path,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ServiceName string            `json:"service_name,omitempty"`
+}
+
+type sbRoute struct {
+	Rules               []sbRule `json:"rules,omitempty"`
+	Final               string   `json:"final,omitempty"`
+	AutoDetectInterface bool     `json:"auto_detect_interface,omitempty"`
+}
+
+type sbRule struct {
+	Type            string   `json:"type,omitempty"`
+	Mode            string   `json:"mode,omitempty"`
+	Rules           []sbRule `json:"rules,omitempty"`
+	Protocol        string   `json:"protocol,omitempty"`
+	Domain          []string `json:"domain,omitempty"`
+	DomainSuffix    []string `json:"domain_suffix,omitempty"`
+	DomainKeyword   []string `json:"domain_keyword,omitempty"`
+	DomainRegex     []string `json:"domain_regex,omitempty"`
+	Geosite         []string `json:"geosite,omitempty"`
+	GeoIP           []string `json:"geoip,omitempty"`
+	IPCIDR          []string `json:"ip_cidr,omitempty"`
+	IPIsPrivate     bool     `json:"ip_is_private,omitempty"`
+	SourceIPCIDR    []string `json:"source_ip_cidr,omitempty"`
+	Port            []int    `json:"port,omitempty"`
+	PortRange       []string `json:"port_range,omitempty"`
+	SourcePort      []int    `json:"source_port,omitempty"`
+	SourcePortRange []string `json:"source_port_range,omitempty"`
+	ProcessName     []string `json:"process_name,omitempty"`
+	ProcessPath     []string `json:"process_path,omitempty"`
+	Network         string   `json:"network,omitempty"`
+	Invert          bool     `json:"invert,omitempty"`
+	Action          string   `json:"action,omitempty"`
+	Outbound        string   `json:"outbound,omitempty"`
+	Method          string   `json:"method,omitempty"`
+}
+
+type sbExperimental struct {
+	ClashAPI *sbClashAPI `json:"clash_api,omitempty"`
+}
+
+type sbClashAPI struct {
+	ExternalController string `json:"external_controller"`
+}
+
+// sbLogLevels mihomo 日志级别对应的 sing-box 日志级别
+var sbLogLevels = map[string]string{
+	"debug":   "debug",
+	"info":    "info",
+	"warning": "warn",
+	"error":   "error",
+}
+
+// sbGroupTypes mihomo 代理组类型对应的 sing-box 出站类型；fallback/load-balance 没有对应类型，按 urltest 导出
+var sbGroupTypes = map[string]string{
+	"select":       "selector",
+	"url-test":     "urltest",
+	"fallback":     "urltest",
+	"load-balance": "urltest",
+}
+
+// exporter sing-box 导出状态
+type exporter struct {
+	report   *Report
+	tags     map[string]bool // 已导出的代理和代理组
+	useBlock bool            // 代理组或 final 引用了 REJECT，需要 block 出站
+}
+
+// exportSingBox 将配置转换为 sing-box JSON：代理、代理组（select/url-test）、规则、DNS 和 TUN。
+// 输出使用 sing-box 1.11 的格式（规则动作、DNS 服务器的 address 写法）。
+func exportSingBox(cfg *config.Config) ([]byte, *Report, error) {
+	e := &exporter{report: &Report{}, tags: make(map[string]bool)}
+	out := sbConfig{}
+
+	out.Log = sbLog{Level: sbLogLevels[cfg.LogLevel]}
+	if cfg.LogLevel == "silent" {
+		out.Log = sbLog{Disabled: true}
+	}
+	if cfg.Mode != "" && cfg.Mode != "rule" {
+		e.report.skip("general", "mode: "+cfg.Mode, "sing-box has no global/direct mode, rules exported as in rule mode")
+	}
+	if cfg.ExternalController != "" {
+		out.Experimental = &sbExperimental{ClashAPI: &sbClashAPI{ExternalController: cfg.ExternalController}}
+	}
+
+	// 先导出节点，代理组只保留能导出的成员
+	var proxies []sbOutbound
+	for _, p := range cfg.Proxies {
+		if ob, ok := e.proxy(p); ok {
+			proxies = append(proxies, ob)
+			e.tags[p.Name] = true
+		}
+	}
+	var groupable []config.ProxyGroup
+	for _, g := range cfg.ProxyGroups {
+		if _, ok := sbGroupTypes[g.Type]; !ok {
+			e.report.skip("group", g.Name, "group type %q has no sing-box equivalent", g.Type)
+			continue
+		}
+		groupable = append(groupable, g)
+		e.tags[g.Name] = true
+	}
+	for _, g := range groupable {
+		out.Outbounds = append(out.Outbounds, e.group(g, cfg.Proxies))
+	}
+	out.Outbounds = append(out.Outbounds, proxies...)
+
+	out.Inbounds = e.inbounds(cfg)
+	out.DNS = e.dns(cfg.DNS)
+	out.Route = e.route(cfg)
+
+	out.Outbounds = append(out.Outbounds, sbOutbound{Type: "direct", Tag: sbDirectTag})
+	if e.useBlock {
+		out.Outbounds = append(out.Outbounds, sbOutbound{Type: "block", Tag: sbBlockTag})
+	}
+
+	e.report.Proxies = len(proxies)
+	e.report.Groups = len(groupable)
+	e.report.Rules = len(out.Route.Rules)
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode sing-box config: %w", err)
+	}
+	return append(data, '\n'), e.report, nil
+}
+
+// proxy 导出单个节点
+func (e *exporter) proxy(p config.Proxy) (sbOutbound, bool) {
+	ob := sbOutbound{Tag: p.Name, Server: p.Server, ServerPort: p.Port}
+	if !p.UDP {
+		ob.Network = "tcp"
+	}
+
+	switch p.Type {
+	case "ss":
+		ob.Type = "shadowsocks"
+		ob.Method, ob.Password = p.Cipher, p.Password
+		if p.Plugin != "" {
+			plugin, opts, ok := sbPlugin(p)
+			if !ok {
+				e.report.skip("proxy", p.Name, "plugin %q has no sing-box equivalent", p.Plugin)
+				return ob, false
+			}
+			ob.Plugin, ob.PluginOpts = plugin, opts
+		}
+	case "vmess":
+		ob.Type = "vmess"
+		ob.UUID, ob.AlterID, ob.Security = p.UUID, p.AlterID, p.Cipher
+	case "vless":
+		ob.Type = "vless"
+		ob.UUID, ob.Flow = p.UUID, p.Flow
+	case "trojan":
+		ob.Type = "trojan"
+		ob.Password = p.Password
+	case "hysteria2":
+		ob.Type = "hysteria2"
+		ob.Network = ""
+		ob.Password = p.Password
+		if p.Obfs != "" {
+			ob.Obfs = &sbObfs{Type: p.Obfs, Password: p.ObfsPassword}
+		}
+		var ok bool
+		if ob.UpMbps, ok = parseMbps(p.Up); !ok {
+			e.report.skip("proxy", p.Name, "bandwidth %q not exported", p.Up)
+		}
+		if ob.DownMbps, ok = parseMbps(p.Down); !ok {
+			e.report.skip("proxy", p.Name, "bandwidth %q not exported", p.Down)
+		}
+	case "http":
+		ob.Type = "http"
+		ob.Network = ""
+		ob.Username, ob.Password = p.Username, p.Password
+	case "socks5":
+		if p.TLS {
+			e.report.skip("proxy", p.Name, "socks5 over TLS has no sing-box equivalent")
+			return ob, false
+		}
+		ob.Type = "socks"
+		ob.Username, ob.Password = p.Username, p.Password
+	default:
+		e.report.skip("proxy", p.Name, "proxy type %q has no sing-box equivalent", p.Type)
+		return ob, false
+	}
+
+	// mihomo 中 trojan 和 hysteria2 总是使用 TLS
+	if p.TLS || p.Type == "trojan" || p.Type == "hysteria2" {
+		tls := &sbTLS{Enabled: true, ServerName: p.ServerName, Insecure: p.SkipCertVerify, ALPN: p.ALPN}
+		if p.SNI != "" {
+			tls.ServerName = p.SNI
+		}
+		if p.ClientFingerprint != "" {
+			tls.UTLS = &sbUTLS{Enabled: true, Fingerprint: p.ClientFingerprint}
+		}
+		if p.RealityOpts != nil {
+			tls.Reality = &sbReality{Enabled: true, PublicKey: p.RealityOpts.PublicKey, ShortID: p.RealityOpts.ShortID}
+		}
+		ob.TLS = tls
+	}
+
+	switch p.Network {
+	case "", "tcp":
+	case "ws":
+		ob.Transport = &sbTransport{Type: "ws"}
+		if p.WSOpts != nil {
+			ob.Transport.Path, ob.Transport.Headers = p.WSOpts.Path, p.WSOpts.Headers
+		}
+	case "grpc":
+		ob.Transport = &sbTransport{Type: "grpc"}
+		if p.GRPCOpts != nil {
+			ob.Transport.ServiceName = p.GRPCOpts.GRPCServiceName
+		}
+	case "h2":
+		ob.Transport = &sbTransport{Type: "http"}
+	default:
+		e.report.skip("proxy", p.Name, "network %q has no sing-box equivalent", p.Network)
+		return ob, false
+	}
+
+	return ob, true
+}
+
+// sbPlugin 导出 shadowsocks 插件（obfs、v2ray-plugin）
+func sbPlugin(p config.Proxy) (string, string, bool) {
+	opt := func(key string) string {
+		return fmt.Sprint(p.PluginOpts[key])
+	}
+
+	var opts []string
+	switch p.Plugin {
+	case "obfs":
+		opts = append(opts, "obfs="+opt("mode"))
+		if _, ok := p.PluginOpts["host"]; ok {
+			opts = append(opts, "obfs-host="+opt("host"))
+		}
+		return "obfs-local", strings.Join(opts, ";"), true
+	case "v2ray-plugin":
+		if mode := opt("mode"); mode != "websocket" {
+			return "", "", false
+		}
+		opts = append(opts, "mode=websocket")
+		for _, key := range []string{"host", "path"} {
+			if _, ok := p.PluginOpts[key]; ok {
+				opts = append(opts, key+"="+opt(key))
+			}
+		}
+		if tls, _ := p.PluginOpts["tls"].(bool); tls {
+			opts = append(opts, "tls")
+		}
+		return "v2ray-plugin", strings.Join(opts, ";"), true
+	}
+	return "", "", false
+}
+
+// parseMbps 解析 hysteria2 带宽（如 "100 Mbps"，不带单位时为 Mbps）
+func parseMbps(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, true
+	}
+	i := 0
+	for i < len(value) && value[i] >= '0' && value[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(value[:i])
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToLower(strings.TrimSpace(value[i:])) {
+	case "", "m", "mbps":
+		return n, true
+	case "g", "gbps":
+		return n * 1000, true
+	}
+	return 0, false
+}
+
+// group 导出代理组，无法导出的成员被移除
+func (e *exporter) group(g config.ProxyGroup, proxies []config.Proxy) sbOutbound {
+	ob := sbOutbound{Type: sbGroupTypes[g.Type], Tag: g.Name}
+	if g.Type == "fallback" || g.Type == "load-balance" {
+		e.report.skip("group", g.Name, "%s exported as urltest", g.Type)
+	}
+
+	members := g.Proxies
+	if g.IncludeAll || g.IncludeAllProxies {
+		for _, p := range proxies {
+			members = append(members, p.Name)
+		}
+	}
+	if len(g.Use) > 0 {
+		e.report.skip("group", g.Name, "proxy providers not exported: %s", strings.Join(g.Use, ", "))
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range members {
+		tag, ok := e.target(name)
+		switch {
+		case !ok:
+			e.report.skip("group", g.Name, "member %q not exported", name)
+			continue
+		case tag == sbBlockTag:
+			e.useBlock = true
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			ob.Outbounds = append(ob.Outbounds, tag)
+		}
+	}
+	if len(ob.Outbounds) == 0 {
+		ob.Outbounds = []string{sbDirectTag}
+	}
+
+	if ob.Type == "urltest" {
+		ob.URL, ob.Tolerance = g.URL, g.Tolerance
+		if g.Interval > 0 {
+			ob.Interval = fmt.Sprintf("%ds", g.Interval)
+		}
+	}
+	return ob
+}
+
+// target 将成员或规则目标映射为出站标签
+func (e *exporter) target(name string) (string, bool) {
+	switch name {
+	case "DIRECT":
+		return sbDirectTag, true
+	case "REJECT", "REJECT-DROP":
+		return sbBlockTag, true
+	}
+	return name, e.tags[name]
+}
+
+// inbounds 导出 TUN 和本地代理端口
+func (e *exporter) inbounds(cfg *config.Config) []sbInbound {
+	var inbounds []sbInbound
+	if cfg.TUN.Enable {
+		inbounds = append(inbounds, sbInbound{
+			Type:                "tun",
+			Tag:                 "tun-in",
+			Address:             []string{sbTUNAddress4, sbTUNAddress6},
+			AutoRoute:           cfg.TUN.AutoRoute,
+			StrictRoute:         cfg.TUN.StrictRoute,
+			Stack:               cfg.TUN.Stack,
+			RouteExcludeAddress: cfg.TUN.RouteExcludeAddress,
+		})
+	}
+
+	listen := "127.0.0.1"
+	if cfg.AllowLan {
+		listen = "::"
+	}
+	if cfg.Port > 0 {
+		inbounds = append(inbounds, sbInbound{Type: "mixed", Tag: "mixed-in", Listen: listen, ListenPort: cfg.Port})
+	}
+	if cfg.SocksPort > 0 {
+		inbounds = append(inbounds, sbInbound{Type: "socks", Tag: "socks-in", Listen: listen, ListenPort: cfg.SocksPort})
+	}
+	return inbounds
+}
+
+// dns 导出 DNS 设置：nameserver 按顺序作为服务器，fake-ip 模式下 A/AAAA 查询使用 fakeip
+func (e *exporter) dns(dns config.DNSConfig) *sbDNS {
+	if !dns.Enable {
+		return nil
+	}
+	if dns.Listen != "" {
+		e.report.skip("dns", "listen: "+dns.Listen, "DNS listener not exported (TUN hijacks DNS instead)")
+	}
+	if len(dns.Fallback) > 0 {
+		e.report.skip("dns", "fallback", "sing-box has no fallback resolvers, %d server(s) not exported", len(dns.Fallback))
+	}
+
+	out := &sbDNS{}
+	needResolver := false
+	for i, ns := range dns.Nameserver {
+		server := sbDNSServer{Tag: fmt.Sprintf("dns-%d", i), Address: ns}
+		if ns == "system" {
+			server.Address = "local"
+		} else if host := dnsHost(ns); host != "" {
+			if _, err := netip.ParseAddr(host); err != nil {
+				server.AddressResolver = "dns-local"
+				needResolver = true
+			}
+		}
+		server.Detour = sbDirectTag
+		out.Servers = append(out.Servers, server)
+	}
+	if len(out.Servers) == 0 {
+		out.Servers = append(out.Servers, sbDNSServer{Tag: "dns-local", Address: "local"})
+	} else if needResolver {
+		out.Servers = append(out.Servers, sbDNSServer{Tag: "dns-local", Address: "local"})
+	}
+	out.Final = out.Servers[0].Tag
+
+	if dns.EnhancedMode == "fake-ip" {
+		prefix, err := netip.ParsePrefix(dns.FakeIPRange)
+		if err != nil {
+			e.report.skip("dns", "fake-ip-range: "+dns.FakeIPRange, "invalid range, fake-ip not exported")
+			return out
+		}
+		out.FakeIP = &sbFakeIP{Enabled: true, Inet4Range: prefix.Masked().String()}
+		out.Servers = append(out.Servers, sbDNSServer{Tag: "fakeip", Address: "fakeip"})
+
+		// fake-ip-filter 中的域名使用真实 DNS
+		if len(dns.FakeIPFilter) > 0 {
+			filter := sbDNSRule{Server: out.Final}
+			for _, pattern := range dns.FakeIPFilter {
+				switch {
+				case strings.HasPrefix(pattern, "+."):
+					filter.DomainSuffix = append(filter.DomainSuffix, pattern[2:])
+				case strings.ContainsAny(pattern, "*?") && !strings.ContainsAny(pattern, "+:"):
+					filter.DomainRegex = append(filter.DomainRegex, wildcardRegexp(pattern))
+				case !strings.ContainsAny(pattern, "*+?:"):
+					filter.Domain = append(filter.Domain, pattern)
+				default:
+					e.report.skip("dns", "fake-ip-filter: "+pattern, "pattern has no sing-box equivalent")
+				}
+			}
+			if len(filter.Domain)+len(filter.DomainSuffix)+len(filter.DomainRegex) > 0 {
+				out.Rules = append(out.Rules, filter)
+			}
+		}
+		out.Rules = append(out.Rules, sbDNSRule{QueryType: []string{"A", "AAAA"}, Server: "fakeip"})
+	}
+	return out
+}
+
+// dnsHost DNS 服务器地址中的主机名（如 tls://dns.google:853 中的 dns.google）
+func dnsHost(address string) string {
+	if !strings.Contains(address, "://") {
+		address = "udp://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// wildcardRegexp 将域名通配符（* 匹配一级域名，? 匹配单个字符）转换为正则表达式
+func wildcardRegexp(pattern string) string {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, `[^.]+`)
+	expr = strings.ReplaceAll(expr, `\?`, `.`)
+	return "^" + expr + "$"
+}
+
+// route 导出规则：连续的同类型、同目标规则合并为一条，MATCH 作为 final
+func (e *exporter) route(cfg *config.Config) sbRoute {
+	route := sbRoute{Final: sbDirectTag, AutoDetectInterface: cfg.TUN.AutoDetectInterface}
+
+	// TUN 的 dns-hijack 对应 hijack-dns 动作，需要先嗅探协议
+	if cfg.TUN.Enable && len(cfg.TUN.DNSHijack) > 0 {
+		route.Rules = append(route.Rules, sbRule{Action: "sniff"}, sbRule{Protocol: "dns", Action: "hijack-dns"})
+	}
+
+	lastField := ""
+	for _, raw := range cfg.Rules {
+		rule, err := config.ParseRule(raw)
+		if err != nil {
+			e.report.skip("rule", raw, "%v", err)
+			continue
+		}
+
+		if rule.Type == "MATCH" {
+			tag, ok := e.target(rule.Target)
+			if !ok {
+				e.report.skip("rule", raw, "target %q not exported", rule.Target)
+				continue
+			}
+			if tag == sbBlockTag {
+				e.useBlock = true
+			}
+			route.Final = tag
+			break
+		}
+
+		out, field, err := sbCondition(rule)
+		if err != nil {
+			e.report.skip("rule", raw, "%v", err)
+			lastField = ""
+			continue
+		}
+		if rule.HasParam("src") {
+			e.report.skip("rule", raw, "src parameter has no sing-box equivalent")
+			lastField = ""
+			continue
+		}
+
+		switch tag, ok := e.target(rule.Target); {
+		case !ok:
+			e.report.skip("rule", raw, "target %q not exported", rule.Target)
+			lastField = ""
+			continue
+		case tag == sbBlockTag:
+			out.Action = "reject"
+			if rule.Target == "REJECT-DROP" {
+				out.Method = "drop"
+			}
+		default:
+			out.Action, out.Outbound = "route", tag
+		}
+
+		// 与上一条规则同字段、同目标时合并（同一字段内的多个值为或）
+		if n := len(route.Rules); field != "" && field == lastField && sameAction(route.Rules[n-1], out) {
+			mergeCondition(&route.Rules[n-1], out)
+			continue
+		}
+		route.Rules = append(route.Rules, out)
+		lastField = field
+	}
+	return route
+}
+
+// sameAction 检查两条规则的动作和目标是否相同
+func sameAction(a, b sbRule) bool {
+	return a.Action == b.Action && a.Outbound == b.Outbound && a.Method == b.Method
+}
+
+// sbCondition 将单条规则的条件转换为 sing-box 规则，返回可合并的字段名（逻辑规则为空）
+func sbCondition(rule *config.Rule) (sbRule, string, error) {
+	var out sbRule
+	payload := rule.Payload
+
+	switch rule.Type {
+	case "DOMAIN":
+		out.Domain = []string{payload}
+		return out, "domain", nil
+	case "DOMAIN-SUFFIX":
+		out.DomainSuffix = []string{payload}
+		return out, "domain_suffix", nil
+	case "DOMAIN-KEYWORD":
+		out.DomainKeyword = []string{payload}
+		return out, "domain_keyword", nil
+	case "DOMAIN-WILDCARD":
+		out.DomainRegex = []string{wildcardRegexp(payload)}
+		return out, "domain_regex", nil
+	case "DOMAIN-REGEX":
+		out.DomainRegex = []string{payload}
+		return out, "domain_regex", nil
+	case "GEOSITE":
+		out.Geosite = []string{strings.ToLower(payload)}
+		return out, "geosite", nil
+	case "GEOIP":
+		if strings.EqualFold(payload, "private") || strings.EqualFold(payload, "lan") {
+			out.IPIsPrivate = true
+			return out, "", nil
+		}
+		out.GeoIP = []string{strings.ToLower(payload)}
+		return out, "geoip", nil
+	case "IP-CIDR", "IP-CIDR6":
+		out.IPCIDR = []string{payload}
+		return out, "ip_cidr", nil
+	case "SRC-IP-CIDR":
+		out.SourceIPCIDR = []string{payload}
+		return out, "source_ip_cidr", nil
+	case "DST-PORT":
+		ports, ranges, err := sbPorts(payload)
+		out.Port, out.PortRange = ports, ranges
+		return out, portField("port", ports, ranges), err
+	case "SRC-PORT":
+		ports, ranges, err := sbPorts(payload)
+		out.SourcePort, out.SourcePortRange = ports, ranges
+		return out, portField("source_port", ports, ranges), err
+	case "PROCESS-NAME":
+		out.ProcessName = []string{payload}
+		return out, "process_name", nil
+	case "PROCESS-PATH":
+		out.ProcessPath = []string{payload}
+		return out, "process_path", nil
+	case "NETWORK":
+		out.Network = strings.ToLower(payload)
+		return out, "", nil
+	case "AND", "OR", "NOT":
+		out.Type, out.Mode = "logical", "and"
+		if rule.Type == "OR" {
+			out.Mode = "or"
+		}
+		out.Invert = rule.Type == "NOT"
+		for _, sub := range rule.Sub {
+			cond, _, err := sbCondition(sub)
+			if err != nil {
+				return out, "", err
+			}
+			out.Rules = append(out.Rules, cond)
+		}
+		return out, "", nil
+	}
+	return out, "", fmt.Errorf("rule type %s has no sing-box equivalent", rule.Type)
+}
+
+// sbPorts 解析端口条件（如 "80/443"、"1000-2000"）为端口列表和 sing-box 端口范围
+func sbPorts(payload string) ([]int, []string, error) {
+	var ports []int
+	var ranges []string
+	for _, part := range strings.Split(payload, "/") {
+		if from, to, ok := strings.Cut(part, "-"); ok {
+			ranges = append(ranges, strings.TrimSpace(from)+":"+strings.TrimSpace(to))
+			continue
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid port %q", part)
+		}
+		ports = append(ports, port)
+	}
+	return ports, ranges, nil
+}
+
+// portField 端口规则的可合并字段名；同时包含端口和范围时不合并
+func portField(name string, ports []int, ranges []string) string {
+	switch {
+	case len(ranges) == 0:
+		return name
+	case len(ports) == 0:
+		return name + "_range"
+	}
+	return ""
+}
+
+// mergeCondition 将同字段规则的值合并到前一条规则
+func mergeCondition(dst *sbRule, src sbRule) {
+	dst.Domain = append(dst.Domain, src.Domain...)
+	dst.DomainSuffix = append(dst.DomainSuffix, src.DomainSuffix...)
+	dst.DomainKeyword = append(dst.DomainKeyword, src.DomainKeyword...)
+	dst.DomainRegex = append(dst.DomainRegex, src.DomainRegex...)
+	dst.Geosite = append(dst.Geosite, src.Geosite...)
+	dst.GeoIP = append(dst.GeoIP, src.GeoIP...)
+	dst.IPCIDR = append(dst.IPCIDR, src.IPCIDR...)
+	dst.SourceIPCIDR = append(dst.SourceIPCIDR, src.SourceIPCIDR...)
+	dst.Port = append(dst.Port, src.Port...)
+	dst.PortRange = append(dst.PortRange, src.PortRange...)
+	dst.SourcePort = append(dst.SourcePort, src.SourcePort...)
+	dst.SourcePortRange = append(dst.SourcePortRange, src.SourcePortRange...)
+	dst.ProcessName = append(dst.ProcessName, src.ProcessName...)
+	dst.ProcessPath = append(dst.ProcessPath, src.ProcessPath...)
+}
@@ -0,0 +1,257 @@
+package convert
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+// exportTestConfig 只包含代理、代理组和规则的配置，TUN 和 DNS 关闭以免生成额外的规则
+func exportTestConfig(proxies []config.Proxy, groups []config.ProxyGroup, rules []string) *config.Config {
+	cfg := config.GetDefaultConfig()
+	cfg.TUN.Enable = false
+	cfg.DNS.Enable = false
+	cfg.Proxies, cfg.ProxyGroups, cfg.Rules = proxies, groups, rules
+	return cfg
+}
+
+func TestSingBoxRoundTrip(t *testing.T) {
+	proxies := []config.Proxy{
+		{
+			Name: "HK", Type: "ss", Server: "hk.example.com", Port: 8388, Cipher: "aes-128-gcm", Password: "hk-pass", UDP: true,
+			Plugin: "obfs", PluginOpts: map[string]interface{}{"mode": "http", "host": "cdn.example.com"},
+		},
+		{
+			Name: "JP", Type: "vmess", Server: "jp.example.com", Port: 443, UUID: "0b7c3e9a-1111-4222-8333-944455556666", Cipher: "auto",
+			TLS: true, ServerName: "jp.example.com", Network: "ws",
+			WSOpts: &config.WSOptions{Path: "/ws", Headers: map[string]string{"Host": "jp.example.com"}},
+		},
+		{
+			Name: "SG", Type: "vless", Server: "sg.example.com", Port: 443, UUID: "0b7c3e9a-1111-4222-8333-944455556666", Flow: "xtls-rprx-vision", UDP: true,
+			TLS: true, ServerName: "www.example.com", ClientFingerprint: "chrome",
+			RealityOpts: &config.RealityOptions{PublicKey: "pubkey", ShortID: "abcd"},
+		},
+		{Name: "US", Type: "trojan", Server: "us.example.com", Port: 443, Password: "us-pass", TLS: true, SNI: "us.example.com", SkipCertVerify: true},
+		{
+			Name: "KR", Type: "hysteria2", Server: "kr.example.com", Port: 443, Password: "hy-pass", UDP: true, SNI: "kr.example.com",
+			ALPN: []string{"h3"}, Obfs: "salamander", ObfsPassword: "obfs-pass", Up: "50 Mbps", Down: "200 Mbps",
+		},
+		{
+			Name: "TW", Type: "vless", Server: "tw.example.com", Port: 443, UUID: "0b7c3e9a-1111-4222-8333-944455556666",
+			TLS: true, Network: "grpc", GRPCOpts: &config.GRPCOptions{GRPCServiceName: "grpc"},
+		},
+		{Name: "Office", Type: "socks5", Server: "10.0.0.1", Port: 1080, Username: "user", Password: "pass", UDP: true},
+		{Name: "Web", Type: "http", Server: "10.0.0.2", Port: 8080},
+	}
+	groups := []config.ProxyGroup{
+		{Name: "Proxy", Type: "select", Proxies: []string{"Auto", "HK", "JP", "SG", "US", "KR", "TW", "DIRECT"}},
+		{Name: "Auto", Type: "url-test", Proxies: []string{"HK", "JP"}, URL: "https://www.gstatic.com/generate_204", Interval: 300, Tolerance: 50},
+		{Name: "Ads", Type: "select", Proxies: []string{"REJECT", "DIRECT"}},
+	}
+	rules := []string{
+		"DOMAIN,api.example.com,Proxy",
+		"DOMAIN-SUFFIX,google.com,Proxy",
+		"DOMAIN-SUFFIX,youtube.com,Proxy",
+		"DOMAIN-KEYWORD,ads,Ads",
+		"DOMAIN-REGEX,^cdn[0-9]+\\.example\\.com$,Auto",
+		"GEOIP,PRIVATE,DIRECT",
+		"IP-CIDR,10.0.0.0/8,Office",
+		"IP-CIDR6,fd00::/8,Office",
+		"SRC-IP-CIDR,192.168.1.10/32,DIRECT",
+		"DST-PORT,22,Office",
+		"DST-PORT,1000-2000,Web",
+		"SRC-PORT,5000-6000,DIRECT",
+		"PROCESS-NAME,curl,DIRECT",
+		"NETWORK,udp,KR",
+		"AND,((DOMAIN-SUFFIX,example.org),(DST-PORT,443)),US",
+		"OR,((PROCESS-NAME,game),(NETWORK,udp)),KR",
+		"DOMAIN,block.example.com,REJECT",
+		"GEOIP,CN,DIRECT",
+		"MATCH,Proxy",
+	}
+	cfg := exportTestConfig(proxies, groups, rules)
+
+	data, report, err := Export(FormatSingBox, cfg)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(report.Skipped) != 0 {
+		t.Errorf("export skipped %+v", report.Skipped)
+	}
+
+	imported, importReport, err := Import(FormatSingBox, data)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	for _, s := range importReport.Skipped {
+		// 入站和 clash_api 属于未导入的段落，其余都应能转换回来
+		if s.Section != "inbounds" && s.Section != "experimental" && s.Section != "log" {
+			t.Errorf("import skipped %+v", s)
+		}
+	}
+
+	if !reflect.DeepEqual(imported.Proxies, cfg.Proxies) {
+		got, _ := json.MarshalIndent(imported.Proxies, "", "  ")
+		want, _ := json.MarshalIndent(cfg.Proxies, "", "  ")
+		t.Errorf("proxies differ\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if !reflect.DeepEqual(imported.ProxyGroups, cfg.ProxyGroups) {
+		t.Errorf("groups = %+v\nwant %+v", imported.ProxyGroups, cfg.ProxyGroups)
+	}
+	if !reflect.DeepEqual(imported.Rules, cfg.Rules) {
+		t.Errorf("rules = %q\nwant %q", imported.Rules, cfg.Rules)
+	}
+}
+
+func TestSingBoxExportGroupMembers(t *testing.T) {
+	proxies := []config.Proxy{
+		{Name: "HK", Type: "ss", Server: "hk.example.com", Port: 8388, Cipher: "aes-128-gcm", Password: "x", UDP: true},
+		{Name: "WG", Type: "wireguard", Server: "wg.example.com", Port: 51820},
+		{Name: "Shadow", Type: "ss", Server: "st.example.com", Port: 443, Cipher: "aes-128-gcm", Password: "x", Plugin: "shadow-tls"},
+	}
+	groups := []config.ProxyGroup{
+		{Name: "Proxy", Type: "select", Proxies: []string{"HK", "WG", "Chain", "HK", "REJECT"}},
+		{Name: "Unusable", Type: "fallback", Proxies: []string{"WG", "Shadow"}},
+		{Name: "Chain", Type: "relay", Proxies: []string{"HK", "WG"}},
+		{Name: "All", Type: "select", IncludeAllProxies: true, Use: []string{"provider"}},
+	}
+	cfg := exportTestConfig(proxies, groups, []string{"MATCH,Proxy"})
+
+	data, report, err := Export(FormatSingBox, cfg)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var out sbConfig
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	outbounds := make(map[string]sbOutbound)
+	var tags []string
+	for _, ob := range out.Outbounds {
+		outbounds[ob.Tag] = ob
+		tags = append(tags, ob.Tag)
+	}
+	if want := []string{"Proxy", "Unusable", "All", "HK", "DIRECT", "REJECT"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("outbounds = %v, want %v", tags, want)
+	}
+
+	tests := []struct {
+		group   string
+		typ     string
+		members []string
+	}{
+		// 无法导出的成员被移除，重复成员只保留一个，REJECT 生成 block 出站
+		{"Proxy", "selector", []string{"HK", "REJECT"}},
+		// 成员全部无法导出时回退为 DIRECT
+		{"Unusable", "urltest", []string{"DIRECT"}},
+		// include-all-proxies 只展开能导出的节点
+		{"All", "selector", []string{"HK"}},
+	}
+	for _, tt := range tests {
+		ob := outbounds[tt.group]
+		if ob.Type != tt.typ || !reflect.DeepEqual(ob.Outbounds, tt.members) {
+			t.Errorf("group %s = %s %v, want %s %v", tt.group, ob.Type, ob.Outbounds, tt.typ, tt.members)
+		}
+	}
+
+	wantSkipped := []Skipped{
+		{Section: "proxy", Item: "WG", Reason: `proxy type "wireguard" has no sing-box equivalent`},
+		{Section: "proxy", Item: "Shadow", Reason: `plugin "shadow-tls" has no sing-box equivalent`},
+		{Section: "group", Item: "Chain", Reason: `group type "relay" has no sing-box equivalent`},
+		{Section: "group", Item: "Proxy", Reason: `member "WG" not exported`},
+		{Section: "group", Item: "Proxy", Reason: `member "Chain" not exported`},
+		{Section: "group", Item: "Unusable", Reason: "fallback exported as urltest"},
+		{Section: "group", Item: "Unusable", Reason: `member "WG" not exported`},
+		{Section: "group", Item: "Unusable", Reason: `member "Shadow" not exported`},
+		{Section: "group", Item: "All", Reason: "proxy providers not exported: provider"},
+		{Section: "group", Item: "All", Reason: `member "WG" not exported`},
+		{Section: "group", Item: "All", Reason: `member "Shadow" not exported`},
+	}
+	if !reflect.DeepEqual(report.Skipped, wantSkipped) {
+		t.Errorf("skipped =\n%+v\nwant\n%+v", report.Skipped, wantSkipped)
+	}
+	if report.Groups != 3 || report.Proxies != 1 {
+		t.Errorf("report counts = %d proxies, %d groups", report.Proxies, report.Groups)
+	}
+}
+
+func TestSbPorts(t *testing.T) {
+	tests := []struct {
+		payload string
+		ports   []int
+		ranges  []string
+		field   string
+		wantErr bool
+	}{
+		{payload: "443", ports: []int{443}, field: "port"},
+		{payload: "80/443", ports: []int{80, 443}, field: "port"},
+		{payload: "1000-2000", ranges: []string{"1000:2000"}, field: "port_range"},
+		{payload: "1000-2000/3000 - 4000", ranges: []string{"1000:2000", "3000:4000"}, field: "port_range"},
+		// 端口和范围混合时不能与相邻规则合并
+		{payload: "80/1000-2000", ports: []int{80}, ranges: []string{"1000:2000"}, field: ""},
+		{payload: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.payload, func(t *testing.T) {
+			ports, ranges, err := sbPorts(tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sbPorts: %v", err)
+			}
+			if !reflect.DeepEqual(ports, tt.ports) || !reflect.DeepEqual(ranges, tt.ranges) {
+				t.Errorf("sbPorts() = %v %v, want %v %v", ports, ranges, tt.ports, tt.ranges)
+			}
+			if field := portField("port", ports, ranges); field != tt.field {
+				t.Errorf("portField() = %q, want %q", field, tt.field)
+			}
+		})
+	}
+}
+
+func TestSingBoxExportPortRules(t *testing.T) {
+	proxies := []config.Proxy{{Name: "HK", Type: "ss", Server: "hk.example.com", Port: 8388, Cipher: "aes-128-gcm", Password: "x", UDP: true}}
+	rules := []string{
+		"DST-PORT,80,HK",
+		"DST-PORT,443/8443,HK",
+		"DST-PORT,1000-2000,HK",
+		"DST-PORT,3000-4000,HK",
+		"DST-PORT,22/5000-6000,HK",
+		"DST-PORT,23,HK",
+		"SRC-PORT,7000-8000,DIRECT",
+		"DST-PORT,http,HK",
+		"MATCH,DIRECT",
+	}
+	cfg := exportTestConfig(proxies, nil, rules)
+
+	data, report, err := Export(FormatSingBox, cfg)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var out sbConfig
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []sbRule{
+		{Port: []int{80, 443, 8443}, Action: "route", Outbound: "HK"},
+		{PortRange: []string{"1000:2000", "3000:4000"}, Action: "route", Outbound: "HK"},
+		{Port: []int{22}, PortRange: []string{"5000:6000"}, Action: "route", Outbound: "HK"},
+		{Port: []int{23}, Action: "route", Outbound: "HK"},
+		{SourcePortRange: []string{"7000:8000"}, Action: "route", Outbound: "DIRECT"},
+	}
+	if !reflect.DeepEqual(out.Route.Rules, want) {
+		t.Errorf("rules =\n%+v\nwant\n%+v", out.Route.Rules, want)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Item != "DST-PORT,http,HK" {
+		t.Errorf("skipped = %+v", report.Skipped)
+	}
+}
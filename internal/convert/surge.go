@@ -0,0 +1,315 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+// surgePolicies Surge 内置策略对应的 mihomo 出站
+var surgePolicies = map[string]string{
+	"DIRECT":         "DIRECT",
+	"REJECT":         "REJECT",
+	"REJECT-TINYGIF": "REJECT",
+	"REJECT-NO-DROP": "REJECT",
+	"REJECT-DROP":    "REJECT-DROP",
+}
+
+// surgeRuleTypes Surge 规则类型对应的 mihomo 规则类型
+var surgeRuleTypes = map[string]string{
+	"DOMAIN":          "DOMAIN",
+	"DOMAIN-SUFFIX":   "DOMAIN-SUFFIX",
+	"DOMAIN-KEYWORD":  "DOMAIN-KEYWORD",
+	"DOMAIN-WILDCARD": "DOMAIN-WILDCARD",
+	"IP-CIDR":         "IP-CIDR",
+	"IP-CIDR6":        "IP-CIDR6",
+	"GEOIP":           "GEOIP",
+	"IP-ASN":          "IP-ASN",
+	"SRC-IP":          "SRC-IP-CIDR",
+	"DEST-PORT":       "DST-PORT",
+	"DST-PORT":        "DST-PORT",
+	"SRC-PORT":        "SRC-PORT",
+	"IN-PORT":         "IN-PORT",
+	"PROCESS-NAME":    "PROCESS-NAME",
+	"PROTOCOL":        "NETWORK",
+	"AND":             "AND",
+	"OR":              "OR",
+	"NOT":             "NOT",
+	"FINAL":           "MATCH",
+}
+
+// surgeGroupTypes Surge 策略组类型对应的 mihomo 代理组类型
+var surgeGroupTypes = map[string]string{
+	"select":       "select",
+	"url-test":     "url-test",
+	"fallback":     "fallback",
+	"load-balance": "load-balance",
+}
+
+// importSurge 转换 Surge 配置的 [Proxy]、[Proxy Group] 和 [Rule] 段落
+func importSurge(b *builder, data []byte) error {
+	sections := parseSections(data)
+	if len(sections) == 0 {
+		return fmt.Errorf("not a Surge config: no [Proxy] or [Rule] section found")
+	}
+
+	for name, target := range surgePolicies {
+		b.alias(name, target)
+	}
+
+	for _, s := range sections {
+		switch strings.ToLower(s.name) {
+		case "proxy":
+			for _, line := range s.lines {
+				b.surgeProxy(line)
+			}
+		case "proxy group":
+			for _, line := range s.lines {
+				b.surgeGroup(line)
+			}
+		case "rule":
+			for _, line := range s.lines {
+				b.surgeRule(line)
+			}
+		default:
+			b.skipSection(s)
+		}
+	}
+	return nil
+}
+
+// surgeProxy 转换 [Proxy] 中的一行，如 "HK = ss, 1.2.3.4, 8388, encrypt-method=aes-128-gcm, password=x"
+func (b *builder) surgeProxy(line string) {
+	name, value, ok := strings.Cut(line, "=")
+	if !ok {
+		b.report.skip("proxy", line, "not a 'name = type, ...' line")
+		return
+	}
+	name = strings.TrimSpace(name)
+	opts, positional := parseOptions(splitList(value))
+	if len(positional) == 0 {
+		b.report.skip("proxy", name, "missing proxy type")
+		return
+	}
+
+	proxyType := strings.ToLower(positional[0])
+	switch proxyType {
+	case "direct":
+		b.alias(name, "DIRECT")
+		return
+	case "reject", "reject-tinygif", "reject-no-drop", "reject-drop":
+		b.alias(name, surgePolicies[strings.ToUpper(proxyType)])
+		return
+	}
+
+	if len(positional) < 3 {
+		b.report.skip("proxy", name, "missing server or port")
+		return
+	}
+	port, err := strconv.Atoi(positional[2])
+	if err != nil {
+		b.report.skip("proxy", name, "invalid port %q", positional[2])
+		return
+	}
+	proxy := &config.Proxy{Name: name, Server: positional[1], Port: port}
+	opts.ignore("tfo", "test-url", "test-timeout", "vmess-aead", "ip-version", "no-error-alert")
+
+	switch proxyType {
+	case "ss":
+		proxy.Type = "ss"
+		proxy.Cipher = opts.get("encrypt-method")
+		proxy.Password = opts.get("password")
+		if obfs := opts.get("obfs"); obfs != "" {
+			proxy.Plugin = "obfs"
+			proxy.PluginOpts = map[string]interface{}{"mode": obfs}
+			if host := opts.get("obfs-host"); host != "" {
+				proxy.PluginOpts["host"] = host
+			}
+		}
+	case "vmess":
+		proxy.Type = "vmess"
+		proxy.UUID = opts.get("username")
+		proxy.Cipher = opts.get("encrypt-method")
+		if proxy.Cipher == "" {
+			proxy.Cipher = "auto"
+		}
+	case "trojan":
+		proxy.Type = "trojan"
+		proxy.Password = opts.get("password")
+	case "hysteria2":
+		proxy.Type = "hysteria2"
+		proxy.Password = opts.get("password")
+		if down := opts.get("download-bandwidth"); down != "" {
+			proxy.Down = down + " Mbps"
+		}
+	case "http", "https", "socks5", "socks5-tls":
+		proxy.Type = "http"
+		if strings.HasPrefix(proxyType, "socks5") {
+			proxy.Type = "socks5"
+		}
+		proxy.TLS = proxyType == "https" || proxyType == "socks5-tls"
+		// 认证信息可以写成 username=/password= 或端口后的两个位置参数
+		proxy.Username, proxy.Password = opts.get("username"), opts.get("password")
+		if len(positional) >= 5 {
+			proxy.Username, proxy.Password = positional[3], positional[4]
+		}
+	default:
+		b.report.skip("proxy", name, "unsupported proxy type %q", proxyType)
+		return
+	}
+
+	proxy.UDP = opts.bool("udp-relay") || proxyType == "hysteria2"
+	if opts.bool("tls") {
+		proxy.TLS = true
+	}
+	if sni := opts.get("sni"); sni != "" {
+		setTLSName(proxy, sni)
+	}
+	proxy.SkipCertVerify = opts.bool("skip-cert-verify")
+	if opts.bool("ws") {
+		path, host := opts.get("ws-path"), ""
+		for _, header := range strings.Split(opts.get("ws-headers"), "|") {
+			if key, value, ok := strings.Cut(header, ":"); ok && strings.EqualFold(strings.TrimSpace(key), "host") {
+				host = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+		}
+		setWebSocket(proxy, path, host)
+	}
+
+	b.reportUnused("proxy", name, opts)
+	b.addProxy(proxy, name)
+}
+
+// surgeGroup 转换 [Proxy Group] 中的一行，如 "Auto = url-test, HK, JP, url=..., interval=600"
+func (b *builder) surgeGroup(line string) {
+	name, value, ok := strings.Cut(line, "=")
+	if !ok {
+		b.report.skip("group", line, "not a 'name = type, ...' line")
+		return
+	}
+	name = strings.TrimSpace(name)
+	opts, positional := parseOptions(splitList(value))
+	if len(positional) == 0 {
+		b.report.skip("group", name, "missing group type")
+		return
+	}
+
+	groupType, ok := surgeGroupTypes[strings.ToLower(positional[0])]
+	if !ok {
+		b.report.skip("group", name, "unsupported group type %q", positional[0])
+		return
+	}
+	group := &config.ProxyGroup{Name: name, Type: groupType, Proxies: positional[1:]}
+	group.URL = opts.get("url")
+	group.Interval, _ = strconv.Atoi(opts.get("interval"))
+	group.Tolerance, _ = strconv.Atoi(opts.get("tolerance"))
+	group.IncludeAllProxies = opts.bool("include-all-proxies")
+	opts.ignore("timeout", "hidden", "no-alert", "evaluate-before-use")
+
+	b.reportUnused("group", name, opts)
+	b.addGroup(group, name)
+}
+
+// surgeSubRule 逻辑规则中的子条件，如 "(DEST-PORT,443)"；嵌套的逻辑规则没有内容部分
+var surgeSubRule = regexp.MustCompile(`\(\s*([A-Za-z0-9-]+)\s*,\s*([^,()]*)`)
+
+// surgeRule 转换 [Rule] 中的一行
+func (b *builder) surgeRule(line string) {
+	ruleType, rest, _ := strings.Cut(line, ",")
+	ruleType = strings.ToUpper(strings.TrimSpace(ruleType))
+	mihomoType, ok := surgeRuleTypes[ruleType]
+	if !ok {
+		b.report.skip("rule", line, "unsupported rule type %s", ruleType)
+		return
+	}
+
+	var payload string
+	var fields []string
+	switch mihomoType {
+	case "MATCH":
+		fields = splitList(rest)
+	case "AND", "OR", "NOT":
+		end := strings.LastIndex(rest, ")")
+		if end < 0 {
+			b.report.skip("rule", line, "malformed logic rule")
+			return
+		}
+		var subErr error
+		payload = surgeSubRule.ReplaceAllStringFunc(rest[:end+1], func(m string) string {
+			match := surgeSubRule.FindStringSubmatch(m)
+			sub := strings.ToUpper(match[1])
+			t, ok := surgeRuleTypes[sub]
+			if !ok {
+				subErr = fmt.Errorf("unsupported rule type %s", sub)
+				return m
+			}
+			subPayload, err := surgePayload(sub, match[2])
+			if err != nil {
+				subErr = err
+				return m
+			}
+			return "(" + t + "," + subPayload
+		})
+		if subErr != nil {
+			b.report.skip("rule", line, "%v", subErr)
+			return
+		}
+		fields = splitList(strings.TrimPrefix(strings.TrimSpace(rest[end+1:]), ","))
+	default:
+		fields = splitList(rest)
+		payload, fields = fields[0], fields[1:]
+	}
+	if len(fields) == 0 || fields[0] == "" {
+		b.report.skip("rule", line, "missing policy")
+		return
+	}
+	target, params := fields[0], fields[1:]
+
+	if mihomoType != "AND" && mihomoType != "OR" && mihomoType != "NOT" {
+		var err error
+		if payload, err = surgePayload(ruleType, payload); err != nil {
+			b.report.skip("rule", line, "%v", err)
+			return
+		}
+	}
+
+	rule := mihomoType
+	if payload != "" {
+		rule += "," + payload
+	}
+	rule += "," + target
+	var dropped []string
+	for _, param := range params {
+		if strings.EqualFold(param, "no-resolve") {
+			rule += ",no-resolve"
+		} else {
+			dropped = append(dropped, param)
+		}
+	}
+	if len(dropped) > 0 {
+		b.report.skip("rule", line, "options not converted: %s", strings.Join(dropped, ", "))
+	}
+	b.addRule(rule, line)
+}
+
+// surgePayload 转换规则内容中与 mihomo 写法不同的部分：SRC-IP 补全前缀长度，PROTOCOL 只支持 TCP/UDP
+func surgePayload(ruleType, payload string) (string, error) {
+	switch ruleType {
+	case "SRC-IP":
+		if !strings.Contains(payload, "/") {
+			if strings.Contains(payload, ":") {
+				return payload + "/128", nil
+			}
+			return payload + "/32", nil
+		}
+	case "PROTOCOL":
+		payload = strings.ToLower(payload)
+		if payload != "tcp" && payload != "udp" {
+			return "", fmt.Errorf("PROTOCOL %s has no mihomo equivalent", strings.ToUpper(payload))
+		}
+	}
+	return payload, nil
+}
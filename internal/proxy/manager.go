@@ -1,22 +1,29 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"syscall"
 
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/internal/subscription"
 	"github.com/clash-fish/clash-fish/internal/system"
 	"github.com/clash-fish/clash-fish/pkg/logger"
 )
 
 // Manager 代理管理器
 type Manager struct {
-	engine     *MihomoEngine
-	configPath string
-	homeDir    string
-	pidFile    string
+	engine      *MihomoEngine
+	configPath  string
+	homeDir     string
+	pidFile     string
+	overrides   map[string]interface{}
+	stopUpdater context.CancelFunc
+	reloadMu    sync.Mutex // SIGHUP 和订阅更新可能同时触发重载
 }
 
 // NewManager 创建代理管理器
@@ -28,6 +35,11 @@ func NewManager(homeDir string) *Manager {
 	}
 }
 
+// SetOverrides 设置启动时叠加到配置上的字段（来自环境变量或命令行标志）
+func (m *Manager) SetOverrides(overrides map[string]interface{}) {
+	m.overrides = overrides
+}
+
 // Start 启动服务
 func (m *Manager) Start() error {
 	// 检查是否已运行
@@ -54,6 +66,7 @@ func (m *Manager) Start() error {
 
 	// 创建 Mihomo 引擎
 	m.engine = NewMihomoEngine(m.configPath, m.homeDir)
+	m.engine.SetOverrides(m.overrides)
 
 	// 启动引擎
 	if err := m.engine.Start(); err != nil {
@@ -67,6 +80,9 @@ func (m *Manager) Start() error {
 		return fmt.Errorf("failed to save PID file: %w", err)
 	}
 
+	// 启动订阅定时更新
+	m.startUpdater()
+
 	logger.Info().
 		Str("config", m.configPath).
 		Str("pid_file", m.pidFile).
@@ -77,6 +93,11 @@ func (m *Manager) Start() error {
 
 // Stop 停止服务
 func (m *Manager) Stop() error {
+	if m.stopUpdater != nil {
+		m.stopUpdater()
+		m.stopUpdater = nil
+	}
+
 	// 检查是否在运行
 	if !m.IsRunning() {
 		return fmt.Errorf("service is not running")
@@ -115,6 +136,47 @@ func (m *Manager) Stop() error {
 	return nil
 }
 
+// Reload 通知运行中的服务重新加载配置（发送 SIGHUP，不会重建 TUN 设备）
+func (m *Manager) Reload() error {
+	if !m.IsRunning() {
+		return fmt.Errorf("service is not running")
+	}
+
+	pid, err := m.readPID()
+	if err != nil {
+		return fmt.Errorf("failed to read PID: %w", err)
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("process not found: %w", err)
+	}
+
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("failed to signal service (try with sudo): %w", err)
+	}
+
+	logger.Info().Int("pid", pid).Msg("Reload signal sent")
+	return nil
+}
+
+// ReloadEngine 在服务进程内重新加载配置，多次重载依次执行
+func (m *Manager) ReloadEngine() error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	if m.engine == nil {
+		return fmt.Errorf("mihomo engine is not running")
+	}
+
+	if err := m.engine.Reload(); err != nil {
+		return fmt.Errorf("failed to reload mihomo engine: %w", err)
+	}
+
+	logger.Info().Str("config", m.configPath).Msg("Configuration reloaded")
+	return nil
+}
+
 // Restart 重启服务
 func (m *Manager) Restart() error {
 	// 如果正在运行，先停止
@@ -186,6 +248,55 @@ func (m *Manager) readPID() (int, error) {
 	return pid, nil
 }
 
+// startUpdater 启动订阅定时更新，当前激活的 profile 更新后自动重载
+func (m *Manager) startUpdater() {
+	cfgMgr := config.NewManager(m.homeDir)
+	cfgMgr.SetOverrides(m.overrides)
+	store := subscription.NewStore(cfgMgr.GetProfilesDir())
+	importer := subscription.NewImporter(store, subscription.NewDownloader(nil))
+	importer.SetValidator(cfgMgr.ValidateReferences)
+	if cfg, err := cfgMgr.Load(); err == nil {
+		if port, ok := m.overrides[config.KeyPort].(int); ok {
+			cfg.Port = port
+		}
+		importer.SetLocalProxy(subscription.LocalProxyAddr(cfg))
+	}
+
+	updater := subscription.NewUpdater(store, importer)
+	updater.OnUpdate(func(profile *subscription.Profile) {
+		active, err := store.GetActive()
+		if err != nil || active != profile.Name {
+			return
+		}
+		if err := m.applyProfile(store, profile.Name); err != nil {
+			logger.Error().Err(err).Str("name", profile.Name).Msg("Failed to apply updated profile")
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopUpdater = cancel
+	updater.Start(ctx)
+}
+
+// applyProfile 将 profile 写入主配置并重载引擎
+func (m *Manager) applyProfile(store *subscription.Store, name string) error {
+	data, err := store.ReadConfig(name)
+	if err != nil {
+		return err
+	}
+
+	if err := config.NewManager(m.homeDir).SaveRaw(data); err != nil {
+		return err
+	}
+
+	if err := m.ReloadEngine(); err != nil {
+		return err
+	}
+
+	logger.Info().Str("name", name).Msg("Active profile updated and reloaded")
+	return nil
+}
+
 // GetConfigPath 获取配置文件路径
 func (m *Manager) GetConfigPath() string {
 	return m.configPath
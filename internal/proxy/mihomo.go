@@ -2,8 +2,8 @@ package proxy
 
 import (
 	"fmt"
-	"os"
 
+	appconfig "github.com/clash-fish/clash-fish/internal/config"
 	"github.com/metacubex/mihomo/config"
 	"github.com/metacubex/mihomo/hub/executor"
 	"github.com/metacubex/mihomo/log"
@@ -13,6 +13,7 @@ import (
 type MihomoEngine struct {
 	configPath string
 	homeDir    string
+	overrides  map[string]interface{}
 	running    bool
 }
 
@@ -34,10 +35,10 @@ func (e *MihomoEngine) Start() error {
 	// 设置 mihomo 的日志级别
 	log.SetLevel(log.INFO)
 
-	// 读取配置文件
-	configData, err := os.ReadFile(e.configPath)
+	// 生成实际生效的配置（主配置 + 本地覆盖层）
+	configData, err := e.effectiveConfig()
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return err
 	}
 
 	// 解析配置
@@ -55,6 +56,18 @@ func (e *MihomoEngine) Start() error {
 	return nil
 }
 
+// SetOverrides 设置叠加到实际配置上的字段（来自环境变量或命令行标志）
+func (e *MihomoEngine) SetOverrides(overrides map[string]interface{}) {
+	e.overrides = overrides
+}
+
+// effectiveConfig 生成实际生效的配置
+func (e *MihomoEngine) effectiveConfig() ([]byte, error) {
+	mgr := appconfig.NewManager(e.homeDir)
+	mgr.SetOverrides(e.overrides)
+	return mgr.BuildEffective()
+}
+
 // Stop 停止 Mihomo 引擎
 func (e *MihomoEngine) Stop() error {
 	if !e.running {
@@ -82,10 +95,10 @@ func (e *MihomoEngine) Reload() error {
 		return fmt.Errorf("mihomo engine is not running")
 	}
 
-	// 读取配置文件
-	configData, err := os.ReadFile(e.configPath)
+	// 生成实际生效的配置（主配置 + 本地覆盖层）
+	configData, err := e.effectiveConfig()
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return err
 	}
 
 	// 解析配置
@@ -0,0 +1,241 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// CombinedGroupName 聚合 profile 中包含所有来源的代理组名称
+const CombinedGroupName = "PROXY"
+
+// reservedNames 不能用作来源名称的保留名称
+var reservedNames = map[string]bool{
+	CombinedGroupName: true,
+	"DIRECT":          true,
+	"REJECT":          true,
+	"GLOBAL":          true,
+}
+
+// sourceConfig 已加载的来源配置
+type sourceConfig struct {
+	name   string
+	config *config.Config
+}
+
+// fetchAggregate 按各来源自己的更新周期刷新来源并重新合并，返回合并结果是否变化。
+// force 为 true 时忽略更新周期，重新下载所有订阅来源。
+func (i *Importer) fetchAggregate(ctx context.Context, profile *Profile, force bool) (bool, error) {
+	now := time.Now()
+	refreshed := false
+
+	sources := make([]sourceConfig, 0, len(profile.Sources))
+	for _, src := range profile.Sources {
+		data, updated, err := i.loadSource(ctx, profile, src, force, now)
+		if err != nil {
+			return false, fmt.Errorf("source '%s': %w", src.Name, err)
+		}
+		refreshed = refreshed || updated
+
+		// 过滤规则作用于每个来源，合并后的来源分组只包含保留的节点
+		data, _, err = ApplyFilter(data, profile.Filter)
+		if err != nil {
+			return false, err
+		}
+
+		cfg, err := ParseContent(data)
+		if err != nil {
+			return false, fmt.Errorf("source '%s': %w", src.Name, err)
+		}
+		sources = append(sources, sourceConfig{name: src.Name, config: cfg})
+	}
+
+	data, err := yaml.Marshal(mergeSources(sources))
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal merged config: %w", err)
+	}
+
+	// 合并结果未变化时只在来源状态变化后更新元数据
+	if hashContent(data) == profile.Hash {
+		if refreshed {
+			return false, i.store.UpdateState(profile)
+		}
+		return false, nil
+	}
+
+	if err := i.check(profile, data, !force); err != nil {
+		return false, err
+	}
+
+	profile.UpdatedAt = now
+	if err := i.store.Save(profile, data); err != nil {
+		return false, err
+	}
+
+	WarnUsage(profile)
+	return true, nil
+}
+
+// loadSource 加载单个来源的配置内容，返回内容以及来源状态是否更新
+func (i *Importer) loadSource(ctx context.Context, profile *Profile, src *Source, force bool, now time.Time) ([]byte, bool, error) {
+	switch src.Kind() {
+	case "profile":
+		data, err := i.store.ReadConfig(src.Profile)
+		if err != nil {
+			return nil, false, err
+		}
+		return data, i.touchSource(src, data, now), nil
+
+	case "file":
+		data, err := readLocalFile(src.File)
+		if err != nil {
+			return nil, false, err
+		}
+		return data, i.touchSource(src, data, now), nil
+	}
+
+	// 订阅来源：未到更新时间且有缓存时直接使用缓存
+	cached, cacheErr := i.store.ReadSource(profile.Name, src.Name)
+	if cacheErr == nil && !force && !src.IsDue(now) {
+		return cached, false, nil
+	}
+
+	data, err := i.download(ctx, []string{src.URL}, profile.Fetch, &src.FetchState, !force && cacheErr == nil)
+	if err != nil {
+		// 下载失败时回退到缓存，避免一个来源拖垮整个 profile
+		if cacheErr == nil {
+			logger.Warn().Err(err).Str("name", profile.Name).Str("source", src.Name).Msg("Source update failed, using cached copy")
+			return cached, false, nil
+		}
+		return nil, false, err
+	}
+
+	if data == nil {
+		return cached, true, nil
+	}
+
+	if _, err := ParseContent(data); err != nil {
+		return nil, false, err
+	}
+	if err := i.store.WriteSource(profile.Name, src.Name, data); err != nil {
+		return nil, false, err
+	}
+	src.Hash = hashContent(data)
+
+	return data, true, nil
+}
+
+// touchSource 本地来源内容变化时更新状态，返回是否变化
+func (i *Importer) touchSource(src *Source, data []byte, now time.Time) bool {
+	hash := hashContent(data)
+	if hash == src.Hash {
+		return false
+	}
+	src.Hash = hash
+	src.UpdatedAt = now
+	return true
+}
+
+// validateSources 检查聚合 profile 的来源定义
+func (i *Importer) validateSources(profile *Profile) error {
+	if profile.URL != "" {
+		return fmt.Errorf("profile '%s' cannot have both url and sources", profile.Name)
+	}
+
+	seen := make(map[string]bool)
+	for _, src := range profile.Sources {
+		if err := ValidateName(src.Name); err != nil {
+			return fmt.Errorf("invalid source name: %w", err)
+		}
+		if reservedNames[src.Name] {
+			return fmt.Errorf("source name '%s' is reserved", src.Name)
+		}
+		if seen[src.Name] {
+			return fmt.Errorf("duplicate source name '%s'", src.Name)
+		}
+		seen[src.Name] = true
+
+		kinds := 0
+		for _, v := range []string{src.URL, src.File, src.Profile} {
+			if v != "" {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			return fmt.Errorf("source '%s' must have exactly one of url, file or profile", src.Name)
+		}
+
+		if src.Profile != "" {
+			if src.Profile == profile.Name {
+				return fmt.Errorf("source '%s' cannot reference the profile itself", src.Name)
+			}
+			if !i.store.Exists(src.Profile) {
+				return fmt.Errorf("source '%s': %w: %s", src.Name, ErrProfileNotFound, src.Profile)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeSources 合并各来源的代理节点：同名节点追加来源后缀，
+// 每个来源生成一个选择组，另有一个包含所有来源的组合选择组
+func mergeSources(sources []sourceConfig) *config.Config {
+	used := make(map[string]bool)
+	for name := range reservedNames {
+		used[name] = true
+	}
+	for _, src := range sources {
+		used[src.name] = true
+	}
+
+	var proxies []config.Proxy
+	var groups []config.ProxyGroup
+	combined := config.ProxyGroup{Name: CombinedGroupName, Type: "select"}
+
+	for _, src := range sources {
+		members := make([]string, 0, len(src.config.Proxies))
+		for _, p := range src.config.Proxies {
+			p.Name = uniqueName(p.Name, src.name, used)
+			proxies = append(proxies, p)
+			members = append(members, p.Name)
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		groups = append(groups, config.ProxyGroup{
+			Name:    src.name,
+			Type:    "select",
+			Proxies: members,
+		})
+		combined.Proxies = append(combined.Proxies, src.name)
+	}
+
+	for _, p := range proxies {
+		combined.Proxies = append(combined.Proxies, p.Name)
+	}
+	combined.Proxies = append(combined.Proxies, "DIRECT")
+
+	cfg := config.GetDefaultConfig()
+	cfg.Proxies = proxies
+	cfg.ProxyGroups = append([]config.ProxyGroup{combined}, groups...)
+	return cfg
+}
+
+// uniqueName 节点名称冲突时追加来源名称（仍冲突则再追加序号）
+func uniqueName(name, source string, used map[string]bool) string {
+	candidate := name
+	if used[candidate] {
+		candidate = fmt.Sprintf("%s [%s]", name, source)
+	}
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s [%s] %d", name, source, n)
+	}
+	used[candidate] = true
+	return candidate
+}
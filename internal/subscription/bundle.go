@@ -0,0 +1,464 @@
+package subscription
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clash-fish/clash-fish/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// BundleVersion 当前导出包格式版本
+	BundleVersion = 1
+
+	// bundleManifestName 导出包清单文件名
+	bundleManifestName = "manifest.yaml"
+
+	// bundleMixinName 导出包中的覆盖层文件名
+	bundleMixinName = "mixin.yaml"
+
+	// bundleRulesDir 导出包中的规则文件目录
+	bundleRulesDir = "rules"
+
+	// bundleSourceName 导入的本地文件 profile 在 profile 目录下的副本
+	bundleSourceName = "source.yaml"
+)
+
+// BundleManifest 导出包清单
+type BundleManifest struct {
+	Version    int          `yaml:"version"`
+	ExportedAt time.Time    `yaml:"exported-at"`
+	Redacted   bool         `yaml:"redacted,omitempty"`
+	Profile    *Profile     `yaml:"profile"`
+	RuleFiles  []BundleRule `yaml:"rule-files,omitempty"`
+	Rejected   []BundleRule `yaml:"rejected-rule-files,omitempty"`
+}
+
+// BundleRule 导出包中的本地规则文件，Document 为引用它的文件（config.yaml 或 mixin.yaml）。
+// 位于配置目录之外而未打包的规则文件只记录原路径 Path
+type BundleRule struct {
+	Provider string `yaml:"provider"`
+	Document string `yaml:"document"`
+	File     string `yaml:"file,omitempty"`
+	Path     string `yaml:"path,omitempty"`
+}
+
+// Bundle 解包后的导出包内容
+type Bundle struct {
+	Manifest  *BundleManifest
+	Config    []byte
+	Mixin     []byte
+	RuleFiles map[string][]byte
+}
+
+// bundleFile 导出包中的单个文件
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	BaseDir   string // 解析相对规则文件路径的目录（mihomo 工作目录），只打包该目录下的规则文件
+	MixinPath string // 覆盖层文件路径，为空或文件不存在时不导出
+	Redact    bool   // 是否脱敏密码、UUID 和订阅令牌
+}
+
+// Export 将 profile 打包为 tar.gz：配置内容、元数据、覆盖层和引用的本地规则文件。
+// 指向 BaseDir 之外的规则文件不会打包，记录在清单的 Rejected 中
+func (s *Store) Export(name string, w io.Writer, opts *ExportOptions) (*BundleManifest, error) {
+	profile, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.ReadConfig(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var mixin []byte
+	if opts.MixinPath != "" {
+		mixin, err = os.ReadFile(opts.MixinPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read mixin: %w", err)
+		}
+	}
+
+	meta := *profile
+	meta.Sources = nil
+	for _, src := range profile.Sources {
+		copied := *src
+		meta.Sources = append(meta.Sources, &copied)
+	}
+
+	manifest := &BundleManifest{
+		Version:    BundleVersion,
+		ExportedAt: time.Now(),
+		Redacted:   opts.Redact,
+		Profile:    &meta,
+	}
+
+	// 收集本地规则文件（在脱敏前读取路径）
+	ruleFiles := make(map[string][]byte)
+	for _, doc := range []bundleFile{{profileFileName, data}, {bundleMixinName, mixin}} {
+		providers, err := fileRuleProviders(doc.data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", doc.name, err)
+		}
+		for _, p := range providers {
+			filePath, err := resolveRuleFile(opts.BaseDir, p.path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rule file of provider '%s': %w", p.name, err)
+			}
+			if filePath == "" {
+				manifest.Rejected = append(manifest.Rejected, BundleRule{
+					Provider: p.name,
+					Document: doc.name,
+					Path:     p.path,
+				})
+				continue
+			}
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rule file of provider '%s': %w", p.name, err)
+			}
+
+			entry := path.Join(bundleRulesDir, fmt.Sprintf("%d-%s", len(manifest.RuleFiles)+1, filepath.Base(p.path)))
+			manifest.RuleFiles = append(manifest.RuleFiles, BundleRule{
+				Provider: p.name,
+				Document: doc.name,
+				File:     entry,
+			})
+			ruleFiles[entry] = content
+		}
+	}
+
+	if opts.Redact {
+		RedactProfile(&meta)
+		if data, err = RedactConfig(data); err != nil {
+			return nil, err
+		}
+		if mixin != nil {
+			if mixin, err = RedactConfig(mixin); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	manifestData, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	files := []bundleFile{
+		{bundleManifestName, manifestData},
+		{profileFileName, data},
+	}
+	if mixin != nil {
+		files = append(files, bundleFile{bundleMixinName, mixin})
+	}
+	for _, rule := range manifest.RuleFiles {
+		files = append(files, bundleFile{rule.File, ruleFiles[rule.File]})
+	}
+
+	for _, f := range files {
+		header := &tar.Header{
+			Name:    f.name,
+			Mode:    0600,
+			Size:    int64(len(f.data)),
+			ModTime: manifest.ExportedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write bundle: %w", err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return nil, fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// resolveRuleFile 解析规则文件的实际路径（包括符号链接），
+// 位于 baseDir 之外时返回空字符串，防止把任意本地文件打包进导出包
+func resolveRuleFile(baseDir, filePath string) (string, error) {
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(baseDir, filePath)
+	}
+	resolved, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		return "", err
+	}
+	base, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(base, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", nil
+	}
+	return resolved, nil
+}
+
+// ReadBundle 读取 tar.gz 导出包，只接受清单中声明的文件
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	defer gz.Close()
+
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(io.LimitReader(tr, maxContentSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		if len(content) > maxContentSize {
+			return nil, fmt.Errorf("bundle entry %s exceeds size limit", header.Name)
+		}
+		entries[path.Clean(header.Name)] = content
+	}
+
+	manifestData, ok := entries[bundleManifestName]
+	if !ok {
+		return nil, fmt.Errorf("invalid bundle: missing %s", bundleManifestName)
+	}
+	var manifest BundleManifest
+	if err := yaml.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if manifest.Version > BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+	if manifest.Profile == nil {
+		return nil, fmt.Errorf("invalid bundle manifest: missing profile")
+	}
+
+	data, ok := entries[profileFileName]
+	if !ok {
+		return nil, fmt.Errorf("invalid bundle: missing %s", profileFileName)
+	}
+
+	bundle := &Bundle{
+		Manifest:  &manifest,
+		Config:    data,
+		Mixin:     entries[bundleMixinName],
+		RuleFiles: make(map[string][]byte),
+	}
+	for _, rule := range manifest.RuleFiles {
+		content, ok := entries[path.Clean(rule.File)]
+		if !ok {
+			return nil, fmt.Errorf("invalid bundle: missing rule file %s", rule.File)
+		}
+		bundle.RuleFiles[rule.File] = content
+	}
+
+	return bundle, nil
+}
+
+// Import 将导出包导入为新的 profile，name 为空时使用包内名称。
+// 规则文件写入 profile 目录下的 rules/，并改写配置内容和 bundle.Mixin 中的路径。
+// 文件先写入临时目录，全部成功后才重命名为 profile 目录，失败时不留下部分内容。
+func (s *Store) Import(bundle *Bundle, name string) (*Profile, error) {
+	profile := *bundle.Manifest.Profile
+	if name != "" {
+		profile.Name = name
+	}
+	if err := ValidateName(profile.Name); err != nil {
+		return nil, err
+	}
+	if s.Exists(profile.Name) {
+		return nil, fmt.Errorf("profile '%s' already exists", profile.Name)
+	}
+	// 导出包来自其他机器，和订阅一样不允许携带密钥引用
+	if err := CheckSecretRefs(bundle.Config); err != nil {
+		return nil, fmt.Errorf("bundle %s rejected: %w", profileFileName, err)
+	}
+	profileDir := s.GetProfileDir(profile.Name)
+	if _, err := os.Stat(profileDir); err == nil {
+		return nil, fmt.Errorf("profile directory %s already exists", profileDir)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(s.dir, ".import-"+profile.Name+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	// 规则文件的最终路径位于 profile 目录下，先写入临时目录
+	data := bundle.Config
+	mixin := bundle.Mixin
+	for _, rule := range bundle.Manifest.RuleFiles {
+		base := path.Base(rule.File)
+		if err := os.MkdirAll(filepath.Join(staging, bundleRulesDir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create rules directory: %w", err)
+		}
+		if err := utils.WriteFileAtomic(filepath.Join(staging, bundleRulesDir, base), bundle.RuleFiles[rule.File], utils.PrivateFileMode); err != nil {
+			return nil, fmt.Errorf("failed to write rule file: %w", err)
+		}
+
+		target := filepath.Join(profileDir, bundleRulesDir, base)
+		switch rule.Document {
+		case profileFileName:
+			data, err = setRuleProviderPath(data, rule.Provider, target)
+		case bundleMixinName:
+			mixin, err = setRuleProviderPath(mixin, rule.Provider, target)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := ParseContent(data); err != nil {
+		return nil, err
+	}
+
+	// 本地文件 profile 的原路径属于导出方的机器，改为 profile 目录下的副本。
+	// 包内内容已经过滤过，副本不再重复过滤（重命名规则可能不是幂等的）
+	if profile.Kind() == "file" {
+		if err := utils.WriteFileAtomic(filepath.Join(staging, bundleSourceName), data, utils.PrivateFileMode); err != nil {
+			return nil, fmt.Errorf("failed to write profile source: %w", err)
+		}
+		profile.File = filepath.Join(profileDir, bundleSourceName)
+		profile.Filter = nil
+	}
+
+	// 脱敏的订阅链接无法下载，关闭自动更新直到恢复链接
+	if bundle.Manifest.Redacted {
+		if IsRedactedURL(profile.URL) {
+			profile.Interval = 0
+		}
+		for _, src := range profile.Sources {
+			if IsRedactedURL(src.URL) {
+				src.Interval = 0
+			}
+		}
+	}
+
+	// 导入的内容视为新 profile 的首个版本，下载状态从头开始
+	profile.ETag = ""
+	profile.LastModified = ""
+
+	if err := os.Rename(staging, profileDir); err != nil {
+		return nil, fmt.Errorf("failed to move imported profile into place: %w", err)
+	}
+	if err := s.Save(&profile, data); err != nil {
+		os.RemoveAll(profileDir)
+		return nil, err
+	}
+	bundle.Mixin = mixin
+	return &profile, nil
+}
+
+// ruleProvider 本地文件类型的规则提供者
+type ruleProvider struct {
+	name string
+	path string
+}
+
+// fileRuleProviders 列出配置中 type 为 file 的规则提供者
+func fileRuleProviders(data []byte) ([]ruleProvider, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var cfg struct {
+		RuleProviders map[string]struct {
+			Type string `yaml:"type"`
+			Path string `yaml:"path"`
+		} `yaml:"rule-providers"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var providers []ruleProvider
+	for name, p := range cfg.RuleProviders {
+		if p.Type == "file" && p.Path != "" {
+			providers = append(providers, ruleProvider{name: name, path: p.Path})
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].name < providers[j].name
+	})
+	return providers, nil
+}
+
+// setRuleProviderPath 修改指定规则提供者的 path，保留其余内容
+func setRuleProviderPath(data []byte, provider, filePath string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	p := mappingValue(mappingValue(documentRoot(&doc), "rule-providers"), provider)
+	target := mappingValue(p, "path")
+	if target == nil {
+		return data, nil
+	}
+	target.Value = filePath
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out, nil
+}
+
+// documentRoot 获取 YAML 文档的根节点
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// mappingValue 获取映射节点中指定键的值，不存在时返回 nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
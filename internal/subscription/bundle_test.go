@@ -0,0 +1,106 @@
+package subscription
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportRejectsSecretRefs(t *testing.T) {
+	store := NewStore(t.TempDir())
+	bundle := &Bundle{
+		Manifest: &BundleManifest{Version: BundleVersion, Profile: &Profile{Name: "shared", URL: "https://example.com/sub"}},
+		Config:   []byte(strings.Replace(testSubscription, "password: secret", "password: file:/etc/shadow", 1)),
+	}
+
+	_, err := store.Import(bundle, "")
+	if err == nil || !strings.Contains(err.Error(), "file:/etc/shadow") {
+		t.Fatalf("expected secret reference error, got %v", err)
+	}
+	if store.Exists("shared") {
+		t.Fatal("bundle with a secret reference was imported")
+	}
+	if _, err := os.Stat(store.GetProfileDir("shared")); !os.IsNotExist(err) {
+		t.Fatalf("profile directory left behind: %v", err)
+	}
+}
+
+func TestExportSkipsRuleFilesOutsideBaseDir(t *testing.T) {
+	baseDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "id_rsa")
+	writeFile(t, outside, "PRIVATE KEY")
+	writeFile(t, filepath.Join(baseDir, "rules", "direct.yaml"), "payload:\n  - example.com\n")
+	// 配置目录内指向外部文件的符号链接同样拒绝
+	if err := os.Symlink(outside, filepath.Join(baseDir, "rules", "link.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	data := testSubscription + `rule-providers:
+  direct:
+    type: file
+    behavior: domain
+    path: ./rules/direct.yaml
+  key:
+    type: file
+    behavior: domain
+    path: ` + outside + `
+  link:
+    type: file
+    behavior: domain
+    path: rules/link.yaml
+  escape:
+    type: file
+    behavior: domain
+    path: ../` + filepath.Base(filepath.Dir(outside)) + `/id_rsa
+`
+	store := NewStore(filepath.Join(baseDir, "profiles"))
+	if err := store.Save(&Profile{Name: "home", URL: "https://example.com/sub"}, []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	manifest, err := store.Export("home", &buf, &ExportOptions{BaseDir: baseDir})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	if len(manifest.RuleFiles) != 1 || manifest.RuleFiles[0].Provider != "direct" {
+		t.Fatalf("exported rule files = %+v, want only direct", manifest.RuleFiles)
+	}
+	var rejected []string
+	for _, rule := range manifest.Rejected {
+		rejected = append(rejected, rule.Provider)
+		if rule.File != "" || rule.Path == "" {
+			t.Errorf("rejected rule %+v should record only the original path", rule)
+		}
+	}
+	if got := strings.Join(rejected, ","); got != "escape,key,link" {
+		t.Errorf("rejected providers = %s, want escape,key,link", got)
+	}
+
+	bundle, err := ReadBundle(&buf)
+	if err != nil {
+		t.Fatalf("ReadBundle: %v", err)
+	}
+	if len(bundle.Manifest.Rejected) != 3 {
+		t.Errorf("manifest in bundle lost rejected rule files: %+v", bundle.Manifest.Rejected)
+	}
+	for name, content := range bundle.RuleFiles {
+		if strings.Contains(string(content), "PRIVATE KEY") {
+			t.Fatalf("bundle entry %s contains a file outside the config directory", name)
+		}
+	}
+}
+
+// writeFile 创建目录并写入测试文件
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,510 @@
+package subscription
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// Format 订阅内容格式
+type Format string
+
+const (
+	// FormatClash Clash/Mihomo YAML 配置
+	FormatClash Format = "clash"
+
+	// FormatShareLinks 分享链接列表（可能经过 base64 编码）
+	FormatShareLinks Format = "share-links"
+
+	// FormatUnknown 无法识别的格式
+	FormatUnknown Format = "unknown"
+)
+
+// DetectFormat 检测订阅内容格式
+func DetectFormat(data []byte) Format {
+	if _, err := ParseContent(data); err == nil {
+		return FormatClash
+	}
+	if len(extractLinks(data)) > 0 {
+		return FormatShareLinks
+	}
+	return FormatUnknown
+}
+
+// Convert 将订阅内容转换为 Clash 配置，Clash YAML 原样返回
+func Convert(data []byte) ([]byte, error) {
+	switch DetectFormat(data) {
+	case FormatClash:
+		return data, nil
+	case FormatShareLinks:
+		proxies, err := ParseShareLinks(data)
+		if err != nil {
+			return nil, err
+		}
+		out, err := yaml.Marshal(config.GetDefaultConfigWithProxies(proxies))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal converted config: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%w: neither clash yaml nor share links", ErrUnsupportedFormat)
+	}
+}
+
+// ParseShareLinks 解析分享链接列表，无法解析的链接会被跳过
+func ParseShareLinks(data []byte) ([]config.Proxy, error) {
+	var proxies []config.Proxy
+	used := make(map[string]bool)
+
+	for _, link := range extractLinks(data) {
+		proxy, err := ParseShareLink(link)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Skipping share link")
+			continue
+		}
+
+		// 节点名称重复时追加序号，跳过已被其他节点占用的名称
+		name := proxy.Name
+		for n := 2; used[proxy.Name]; n++ {
+			proxy.Name = fmt.Sprintf("%s %d", name, n)
+		}
+		used[proxy.Name] = true
+
+		proxies = append(proxies, *proxy)
+	}
+
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("%w: no valid share links found", ErrUnsupportedFormat)
+	}
+
+	return proxies, nil
+}
+
+// ParseShareLink 解析单个分享链接
+func ParseShareLink(link string) (*config.Proxy, error) {
+	scheme, _, ok := strings.Cut(link, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid share link: %s", link)
+	}
+
+	switch strings.ToLower(scheme) {
+	case "ss":
+		return parseShadowsocks(link)
+	case "vmess":
+		return parseVmess(link)
+	case "trojan":
+		return parseTrojan(link)
+	case "vless":
+		return parseVless(link)
+	case "hysteria2", "hy2":
+		return parseHysteria2(link)
+	default:
+		return nil, fmt.Errorf("unsupported share link scheme: %s", scheme)
+	}
+}
+
+// extractLinks 从订阅内容中提取分享链接，自动处理 base64 编码
+func extractLinks(data []byte) []string {
+	text := string(bytes.TrimSpace(data))
+	if !strings.Contains(text, "://") {
+		decoded, err := decodeBase64(text)
+		if err != nil {
+			return nil
+		}
+		text = string(decoded)
+	}
+
+	var links []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "://") {
+			links = append(links, line)
+		}
+	}
+	return links
+}
+
+// decodeBase64 兼容标准/URL 安全、带或不带填充的 base64
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	}
+
+	var lastErr error
+	for _, enc := range encodings {
+		decoded, err := enc.DecodeString(s)
+		if err == nil {
+			return decoded, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// parseShadowsocks 解析 ss:// 链接（SIP002 及旧版 base64 格式）
+func parseShadowsocks(link string) (*config.Proxy, error) {
+	body := link[len("ss://"):]
+	body, fragment, _ := strings.Cut(body, "#")
+
+	// 旧版格式：ss://base64(method:password@host:port)
+	if !strings.Contains(body, "@") {
+		main, query, _ := strings.Cut(body, "?")
+		decoded, err := decodeBase64(main)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ss link: %w", err)
+		}
+		body = string(decoded)
+		if query != "" {
+			body += "?" + query
+		}
+	}
+
+	u, err := url.Parse("ss://" + body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ss link: %w", err)
+	}
+
+	// userinfo 可能是 base64(method:password) 或 URL 编码的 method:password
+	var method, password string
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			method, password = u.User.Username(), pass
+		} else if decoded, err := decodeBase64(u.User.Username()); err == nil {
+			method, password, _ = strings.Cut(string(decoded), ":")
+		}
+	}
+	if method == "" || password == "" {
+		return nil, fmt.Errorf("invalid ss link: missing method or password")
+	}
+
+	proxy, err := newProxy("ss", u, fragment, 0)
+	if err != nil {
+		return nil, err
+	}
+	proxy.Cipher = method
+	proxy.Password = password
+	proxy.UDP = true
+
+	if plugin := u.Query().Get("plugin"); plugin != "" {
+		parseSSPlugin(proxy, plugin)
+	}
+
+	return proxy, nil
+}
+
+// parseSSPlugin 解析 SIP003 插件参数
+func parseSSPlugin(proxy *config.Proxy, plugin string) {
+	parts := strings.Split(plugin, ";")
+	opts := make(map[string]interface{})
+
+	switch parts[0] {
+	case "obfs-local", "simple-obfs":
+		proxy.Plugin = "obfs"
+		for _, part := range parts[1:] {
+			key, value, _ := strings.Cut(part, "=")
+			switch key {
+			case "obfs":
+				opts["mode"] = value
+			case "obfs-host":
+				opts["host"] = value
+			}
+		}
+	case "v2ray-plugin":
+		proxy.Plugin = "v2ray-plugin"
+		opts["mode"] = "websocket"
+		for _, part := range parts[1:] {
+			key, value, _ := strings.Cut(part, "=")
+			switch key {
+			case "mode":
+				opts["mode"] = value
+			case "host", "path":
+				opts[key] = value
+			case "tls":
+				opts["tls"] = true
+			}
+		}
+	default:
+		proxy.Plugin = parts[0]
+	}
+
+	if len(opts) > 0 {
+		proxy.PluginOpts = opts
+	}
+}
+
+// vmessLink vmess:// 链接中的 JSON 结构（v2rayN 格式）
+type vmessLink struct {
+	PS   string     `json:"ps"`
+	Add  string     `json:"add"`
+	Port flexString `json:"port"`
+	ID   string     `json:"id"`
+	Aid  flexString `json:"aid"`
+	Scy  string     `json:"scy"`
+	Net  string     `json:"net"`
+	Type string     `json:"type"`
+	Host string     `json:"host"`
+	Path string     `json:"path"`
+	TLS  string     `json:"tls"`
+	SNI  string     `json:"sni"`
+	ALPN string     `json:"alpn"`
+	FP   string     `json:"fp"`
+}
+
+// flexString 兼容 JSON 中写成数字或字符串的字段
+type flexString string
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*f = flexString(n.String())
+	return nil
+}
+
+// parseVmess 解析 vmess:// 链接
+func parseVmess(link string) (*config.Proxy, error) {
+	decoded, err := decodeBase64(link[len("vmess://"):])
+	if err != nil {
+		return nil, fmt.Errorf("invalid vmess link: %w", err)
+	}
+
+	var v vmessLink
+	if err := json.Unmarshal(decoded, &v); err != nil {
+		return nil, fmt.Errorf("invalid vmess link: %w", err)
+	}
+
+	port, err := strconv.Atoi(string(v.Port))
+	if err != nil || v.Add == "" || v.ID == "" {
+		return nil, fmt.Errorf("invalid vmess link: missing server, port or id")
+	}
+	aid, _ := strconv.Atoi(string(v.Aid))
+
+	proxy := &config.Proxy{
+		Name:              v.PS,
+		Type:              "vmess",
+		Server:            v.Add,
+		Port:              port,
+		UUID:              v.ID,
+		AlterID:           aid,
+		Cipher:            v.Scy,
+		UDP:               true,
+		TLS:               v.TLS == "tls",
+		ServerName:        v.SNI,
+		ClientFingerprint: v.FP,
+	}
+	if proxy.Name == "" {
+		proxy.Name = net.JoinHostPort(v.Add, string(v.Port))
+	}
+	if proxy.Cipher == "" {
+		proxy.Cipher = "auto"
+	}
+	if v.ALPN != "" {
+		proxy.ALPN = strings.Split(v.ALPN, ",")
+	}
+
+	applyTransport(proxy, v.Net, v.Host, v.Path, v.Path)
+	if proxy.ServerName == "" && proxy.TLS {
+		proxy.ServerName = v.Host
+	}
+
+	return proxy, nil
+}
+
+// parseTrojan 解析 trojan:// 链接
+func parseTrojan(link string) (*config.Proxy, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trojan link: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid trojan link: missing password")
+	}
+
+	proxy, err := newProxy("trojan", u, u.Fragment, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	proxy.Password = u.User.Username()
+	proxy.UDP = true
+	proxy.SNI = firstNonEmpty(q.Get("sni"), q.Get("peer"))
+	proxy.SkipCertVerify = isTrue(q.Get("allowInsecure"))
+	proxy.ClientFingerprint = q.Get("fp")
+	if alpn := q.Get("alpn"); alpn != "" {
+		proxy.ALPN = strings.Split(alpn, ",")
+	}
+	applyTransport(proxy, q.Get("type"), q.Get("host"), q.Get("path"), q.Get("serviceName"))
+
+	return proxy, nil
+}
+
+// parseVless 解析 vless:// 链接
+func parseVless(link string) (*config.Proxy, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vless link: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid vless link: missing uuid")
+	}
+
+	proxy, err := newProxy("vless", u, u.Fragment, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	proxy.UUID = u.User.Username()
+	proxy.UDP = true
+	proxy.Flow = q.Get("flow")
+	proxy.ServerName = q.Get("sni")
+	proxy.ClientFingerprint = q.Get("fp")
+	proxy.SkipCertVerify = isTrue(q.Get("allowInsecure"))
+	if alpn := q.Get("alpn"); alpn != "" {
+		proxy.ALPN = strings.Split(alpn, ",")
+	}
+
+	switch q.Get("security") {
+	case "tls":
+		proxy.TLS = true
+	case "reality":
+		proxy.TLS = true
+		proxy.RealityOpts = &config.RealityOptions{
+			PublicKey: q.Get("pbk"),
+			ShortID:   q.Get("sid"),
+		}
+	}
+	applyTransport(proxy, q.Get("type"), q.Get("host"), q.Get("path"), q.Get("serviceName"))
+
+	return proxy, nil
+}
+
+// parseHysteria2 解析 hysteria2:// 或 hy2:// 链接
+func parseHysteria2(link string) (*config.Proxy, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hysteria2 link: %w", err)
+	}
+
+	// hysteria2 链接可以省略端口，默认 443
+	proxy, err := newProxy("hysteria2", u, u.Fragment, 443)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	if u.User != nil {
+		// 密码可能写成 user:pass 形式
+		proxy.Password = u.User.String()
+		if decoded, err := url.PathUnescape(proxy.Password); err == nil {
+			proxy.Password = decoded
+		}
+	}
+	proxy.SNI = q.Get("sni")
+	proxy.SkipCertVerify = isTrue(q.Get("insecure"))
+	proxy.Obfs = q.Get("obfs")
+	proxy.ObfsPassword = q.Get("obfs-password")
+	proxy.Up = bandwidth(q.Get("up"))
+	proxy.Down = bandwidth(q.Get("down"))
+	if alpn := q.Get("alpn"); alpn != "" {
+		proxy.ALPN = strings.Split(alpn, ",")
+	}
+
+	return proxy, nil
+}
+
+// bandwidth 带宽参数：纯数字按 Mbps 处理，带单位时原样使用
+func bandwidth(value string) string {
+	if value == "" {
+		return ""
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return value + " Mbps"
+	}
+	return value
+}
+
+// newProxy 根据 URL 的 host/port/fragment 创建代理基础信息，defaultPort 为 0 时端口必填
+func newProxy(proxyType string, u *url.URL, fragment string, defaultPort int) (*config.Proxy, error) {
+	host := u.Hostname()
+	port, err := strconv.Atoi(u.Port())
+	if u.Port() == "" && defaultPort > 0 {
+		port, err = defaultPort, nil
+	}
+	if host == "" || err != nil {
+		return nil, fmt.Errorf("invalid %s link: missing server or port", proxyType)
+	}
+
+	name, err := url.PathUnescape(fragment)
+	if err != nil {
+		name = fragment
+	}
+	if name == "" {
+		name = u.Host
+	}
+
+	return &config.Proxy{
+		Name:   name,
+		Type:   proxyType,
+		Server: host,
+		Port:   port,
+	}, nil
+}
+
+// applyTransport 设置 ws/grpc 等传输层参数
+func applyTransport(proxy *config.Proxy, network, host, path, serviceName string) {
+	switch network {
+	case "ws":
+		proxy.Network = "ws"
+		opts := &config.WSOptions{Path: path}
+		if host != "" {
+			opts.Headers = map[string]string{"Host": host}
+		}
+		proxy.WSOpts = opts
+	case "grpc":
+		proxy.Network = "grpc"
+		proxy.GRPCOpts = &config.GRPCOptions{GRPCServiceName: serviceName}
+	case "h2", "http":
+		proxy.Network = network
+	}
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// isTrue 判断查询参数是否表示 true
+func isTrue(value string) bool {
+	return value == "1" || strings.EqualFold(value, "true")
+}
@@ -0,0 +1,138 @@
+package subscription
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+const testUUID = "0b7c3e9a-1111-4222-8333-944455556666"
+
+func TestParseShareLink(t *testing.T) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	vmess := `{"v":"2","ps":"JP","add":"jp.example.com","port":443,"id":"` + testUUID + `","aid":"0","net":"ws","host":"cdn.example.com","path":"/ws","tls":"tls"}`
+
+	tests := []struct {
+		name string
+		link string
+		want *config.Proxy
+	}{
+		{
+			name: "ss sip002 with plugin",
+			link: "ss://" + b64([]byte("aes-128-gcm:pass")) + "@1.2.3.4:8388?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dcdn.example.com#HK%2001",
+			want: &config.Proxy{
+				Name: "HK 01", Type: "ss", Server: "1.2.3.4", Port: 8388, Cipher: "aes-128-gcm", Password: "pass", UDP: true,
+				Plugin: "obfs", PluginOpts: map[string]interface{}{"mode": "http", "host": "cdn.example.com"},
+			},
+		},
+		{
+			name: "ss legacy base64",
+			link: "ss://" + b64([]byte("aes-256-gcm:pw@ss.example.com:443")) + "#Legacy",
+			want: &config.Proxy{Name: "Legacy", Type: "ss", Server: "ss.example.com", Port: 443, Cipher: "aes-256-gcm", Password: "pw", UDP: true},
+		},
+		{
+			name: "ss plain userinfo without name",
+			link: "ss://chacha20-ietf-poly1305:pw@ss.example.com:1234",
+			want: &config.Proxy{Name: "ss.example.com:1234", Type: "ss", Server: "ss.example.com", Port: 1234, Cipher: "chacha20-ietf-poly1305", Password: "pw", UDP: true},
+		},
+		{
+			name: "vmess ws tls",
+			link: "vmess://" + base64.StdEncoding.EncodeToString([]byte(vmess)),
+			want: &config.Proxy{
+				Name: "JP", Type: "vmess", Server: "jp.example.com", Port: 443, UUID: testUUID, Cipher: "auto", UDP: true,
+				TLS: true, ServerName: "cdn.example.com", Network: "ws",
+				WSOpts: &config.WSOptions{Path: "/ws", Headers: map[string]string{"Host": "cdn.example.com"}},
+			},
+		},
+		{
+			name: "trojan grpc",
+			link: "trojan://pass@us.example.com:443?sni=us.example.com&allowInsecure=1&type=grpc&serviceName=svc#US",
+			want: &config.Proxy{
+				Name: "US", Type: "trojan", Server: "us.example.com", Port: 443, Password: "pass", UDP: true,
+				SNI: "us.example.com", SkipCertVerify: true, Network: "grpc", GRPCOpts: &config.GRPCOptions{GRPCServiceName: "svc"},
+			},
+		},
+		{
+			name: "vless reality",
+			link: "vless://" + testUUID + "@sg.example.com:443?security=reality&pbk=key&sid=ab&sni=www.example.com&fp=chrome&flow=xtls-rprx-vision&type=tcp#SG",
+			want: &config.Proxy{
+				Name: "SG", Type: "vless", Server: "sg.example.com", Port: 443, UUID: testUUID, UDP: true, Flow: "xtls-rprx-vision",
+				TLS: true, ServerName: "www.example.com", ClientFingerprint: "chrome",
+				RealityOpts: &config.RealityOptions{PublicKey: "key", ShortID: "ab"},
+			},
+		},
+		{
+			name: "hysteria2 with port and bandwidth",
+			link: "hysteria2://secret@kr.example.com:8443?sni=kr.example.com&obfs=salamander&obfs-password=op&up=50&down=200%20Mbps#KR",
+			want: &config.Proxy{
+				Name: "KR", Type: "hysteria2", Server: "kr.example.com", Port: 8443, Password: "secret",
+				SNI: "kr.example.com", Obfs: "salamander", ObfsPassword: "op", Up: "50 Mbps", Down: "200 Mbps",
+			},
+		},
+		{
+			name: "hy2 without port defaults to 443",
+			link: "hy2://secret@kr.example.com?insecure=1#KR",
+			want: &config.Proxy{Name: "KR", Type: "hysteria2", Server: "kr.example.com", Port: 443, Password: "secret", SkipCertVerify: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseShareLink(tt.link)
+			if err != nil {
+				t.Fatalf("ParseShareLink: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseShareLink() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShareLinkErrors(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"ss://" + base64.RawURLEncoding.EncodeToString([]byte("aes-128-gcm")) + "@1.2.3.4:8388", "missing method or password"},
+		{"vmess://" + base64.StdEncoding.EncodeToString([]byte(`{"add":"jp.example.com","port":"x","id":"`+testUUID+`"}`)), "missing server, port or id"},
+		{"trojan://us.example.com:443", "missing password"},
+		{"vless://" + testUUID + "@sg.example.com", "missing server or port"},
+		{"hysteria2://secret@:443", "missing server or port"},
+		{"tuic://x@y:1", "unsupported share link scheme"},
+		{"not a link", "invalid share link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			_, err := ParseShareLink(tt.link)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseShareLinksUniqueNames(t *testing.T) {
+	data := strings.Join([]string{
+		"trojan://p@a.example.com:443#A",
+		"trojan://p@b.example.com:443#A%202",
+		"trojan://p@c.example.com:443#A",
+		"trojan://p@d.example.com:443#A",
+		"trojan://broken",
+	}, "\n")
+
+	proxies, err := ParseShareLinks([]byte(base64.StdEncoding.EncodeToString([]byte(data))))
+	if err != nil {
+		t.Fatalf("ParseShareLinks: %v", err)
+	}
+	var names []string
+	for _, p := range proxies {
+		names = append(names, p.Name)
+	}
+	if want := []string{"A", "A 2", "A 3", "A 4"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,129 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/pkg/logger"
+)
+
+// DefaultRetries 每个订阅地址默认的重试次数
+const DefaultRetries = 2
+
+// retryBackoff 首次重试前的等待时间，之后每次翻倍
+var retryBackoff = 2 * time.Second
+
+// ErrLocalProxyUnavailable 未配置本地 HTTP 代理端口
+var ErrLocalProxyUnavailable = errors.New("local http proxy port is not configured")
+
+// FetchOptions profile 的下载选项
+type FetchOptions struct {
+	UserAgent string            `yaml:"user-agent,omitempty"`
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	ViaProxy  bool              `yaml:"via-proxy,omitempty"` // 通过本地 HTTP 代理端口下载
+	Mirrors   []string          `yaml:"mirrors,omitempty"`   // 主地址失败后依次尝试的备用地址
+	Retries   int               `yaml:"retries,omitempty"`   // 每个地址的重试次数，0 表示使用默认值，负数表示不重试
+}
+
+// IsEmpty 检查下载选项是否没有任何设置
+func (o *FetchOptions) IsEmpty() bool {
+	return o == nil || (o.UserAgent == "" && len(o.Headers) == 0 &&
+		!o.ViaProxy && len(o.Mirrors) == 0 && o.Retries == 0)
+}
+
+// GetRetries 获取每个地址的重试次数
+func (o *FetchOptions) GetRetries() int {
+	switch {
+	case o == nil || o.Retries == 0:
+		return DefaultRetries
+	case o.Retries < 0:
+		return 0
+	}
+	return o.Retries
+}
+
+// LocalProxyAddr 根据主配置获取本地 HTTP 代理地址，未配置端口时返回空字符串
+func LocalProxyAddr(cfg *config.Config) string {
+	if cfg == nil || cfg.Port <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("127.0.0.1:%d", cfg.Port)
+}
+
+// fetchWithRetry 依次尝试主地址和备用地址，每个地址失败后按指数退避重试
+func (i *Importer) fetchWithRetry(ctx context.Context, urls []string, opts *FetchOptions, base *FetchRequest) (*FetchResult, error) {
+	if opts != nil {
+		base.UserAgent = opts.UserAgent
+		base.Headers = opts.Headers
+	}
+	if opts != nil && opts.ViaProxy {
+		if i.localProxy == "" {
+			return nil, ErrLocalProxyUnavailable
+		}
+		base.Proxy = &url.URL{Scheme: "http", Host: i.localProxy}
+	}
+
+	retries := opts.GetRetries()
+	var lastErr error
+	for n, u := range urls {
+		// 从脱敏导出包导入的链接需要先恢复
+		if IsRedactedURL(u) {
+			lastErr = fmt.Errorf("subscription url %s is redacted, restore it before updating", u)
+			continue
+		}
+
+		req := *base
+		req.URL = u
+
+		backoff := retryBackoff
+		for attempt := 0; attempt <= retries; attempt++ {
+			if attempt > 0 {
+				logger.Debug().Err(lastErr).Str("url", RedactURL(u)).Int("attempt", attempt).Msg("Retrying subscription download")
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+			}
+
+			result, err := i.downloader.Fetch(ctx, &req)
+			if err == nil {
+				return result, nil
+			}
+			lastErr = err
+
+			// 客户端错误（如链接失效、鉴权失败）重试无意义，直接换下一个地址
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) && !statusErr.Temporary() {
+				break
+			}
+		}
+
+		if n+1 < len(urls) {
+			logger.Warn().Err(lastErr).Str("url", RedactURL(u)).Msg("Subscription download failed, trying next mirror")
+		}
+	}
+
+	return nil, lastErr
+}
+
+// StatusError 订阅服务器返回了非预期的 HTTP 状态码
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to download subscription: unexpected status %s", e.Status)
+}
+
+// Temporary 服务端错误和限流可以重试
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+}
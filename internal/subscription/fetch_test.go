@@ -0,0 +1,163 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSubscription = `proxies:
+  - name: HK
+    type: ss
+    server: 1.2.3.4
+    port: 8388
+    cipher: aes-128-gcm
+    password: secret
+`
+
+func newTestImporter(t *testing.T) *Importer {
+	t.Helper()
+	old := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = old })
+	return NewImporter(NewStore(t.TempDir()), NewDownloader(nil))
+}
+
+func TestDownloadNotModified(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Header().Set(UserInfoHeader, "upload=1; download=2; total=10")
+		w.Write([]byte(testSubscription))
+	}))
+	defer server.Close()
+
+	i := newTestImporter(t)
+	state := &FetchState{}
+
+	// 首次下载保存 ETag 和 Last-Modified
+	data, err := i.download(context.Background(), []string{server.URL}, nil, state, true)
+	if err != nil {
+		t.Fatalf("first download: %v", err)
+	}
+	if string(data) != testSubscription {
+		t.Fatalf("first download returned %q", data)
+	}
+	if state.ETag != etag || state.LastModified != lastModified {
+		t.Fatalf("state not updated: %+v", state)
+	}
+	if state.UserInfo == nil || state.UserInfo.Total != 10 {
+		t.Fatalf("userinfo not recorded: %+v", state.UserInfo)
+	}
+
+	// 条件请求返回 304，数据为 nil 且校验字段保持不变
+	data, err = i.download(context.Background(), []string{server.URL}, nil, state, true)
+	if err != nil {
+		t.Fatalf("conditional download: %v", err)
+	}
+	if data != nil {
+		t.Fatalf("expected nil data on 304, got %q", data)
+	}
+	if state.ETag != etag || state.LastModified != lastModified {
+		t.Fatalf("state changed on 304: %+v", state)
+	}
+
+	// 强制更新不发送条件请求头
+	data, err = i.download(context.Background(), []string{server.URL}, nil, state, false)
+	if err != nil || data == nil {
+		t.Fatalf("forced download: data=%q err=%v", data, err)
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", hits.Load())
+	}
+}
+
+func TestDownloadMirrorFallback(t *testing.T) {
+	var primaryHits, mirrorHits atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits.Add(1)
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorHits.Add(1)
+		if r.Header.Get("User-Agent") != "custom-agent" {
+			t.Errorf("mirror request has User-Agent %q", r.Header.Get("User-Agent"))
+		}
+		w.Write([]byte(testSubscription))
+	}))
+	defer mirror.Close()
+
+	i := newTestImporter(t)
+	opts := &FetchOptions{UserAgent: "custom-agent", Mirrors: []string{mirror.URL}}
+	data, err := i.download(context.Background(), []string{primary.URL, mirror.URL}, opts, &FetchState{}, false)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if string(data) != testSubscription {
+		t.Fatalf("unexpected data %q", data)
+	}
+	// 404 不重试，直接换备用地址
+	if primaryHits.Load() != 1 || mirrorHits.Load() != 1 {
+		t.Fatalf("expected 1 primary and 1 mirror request, got %d and %d", primaryHits.Load(), mirrorHits.Load())
+	}
+}
+
+func TestDownloadRetryExhausted(t *testing.T) {
+	var primaryHits, mirrorHits atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorHits.Add(1)
+		http.Error(w, "busy", http.StatusTooManyRequests)
+	}))
+	defer mirror.Close()
+
+	i := newTestImporter(t)
+	opts := &FetchOptions{Retries: 2}
+	_, err := i.download(context.Background(), []string{primary.URL, mirror.URL}, opts, &FetchState{}, false)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the last error to come from the mirror, got %d", statusErr.StatusCode)
+	}
+	// 每个地址 1 次请求 + 2 次重试
+	if primaryHits.Load() != 3 || mirrorHits.Load() != 3 {
+		t.Fatalf("expected 3 requests per url, got %d and %d", primaryHits.Load(), mirrorHits.Load())
+	}
+}
+
+func TestDownloadNoRetry(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	i := newTestImporter(t)
+	if _, err := i.download(context.Background(), []string{server.URL}, &FetchOptions{Retries: -1}, &FetchState{}, false); err == nil {
+		t.Fatal("expected an error")
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("expected a single request with retries disabled, got %d", hits.Load())
+	}
+}
@@ -0,0 +1,291 @@
+package subscription
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// Filter 导入时的节点过滤与重命名设置，正则为空表示不限制
+type Filter struct {
+	IncludeName   string       `yaml:"include-name,omitempty"`
+	ExcludeName   string       `yaml:"exclude-name,omitempty"`
+	IncludeType   string       `yaml:"include-type,omitempty"`
+	ExcludeType   string       `yaml:"exclude-type,omitempty"`
+	IncludeServer string       `yaml:"include-server,omitempty"`
+	ExcludeServer string       `yaml:"exclude-server,omitempty"`
+	Rename        []RenameRule `yaml:"rename,omitempty"`
+}
+
+// RenameRule 节点重命名规则，Replace 支持 $1 等正则分组引用
+type RenameRule struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// FilterResult 过滤统计
+type FilterResult struct {
+	Kept    int
+	Removed int
+	Renamed int
+	Pruned  int // 目标节点被过滤而删除的规则
+}
+
+// compiledFilter 编译后的过滤器
+type compiledFilter struct {
+	includeName, excludeName     *regexp.Regexp
+	includeType, excludeType     *regexp.Regexp
+	includeServer, excludeServer *regexp.Regexp
+	rename                       []*regexp.Regexp
+	replace                      []string
+}
+
+// IsEmpty 检查过滤器是否没有任何设置
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (f.IncludeName == "" && f.ExcludeName == "" &&
+		f.IncludeType == "" && f.ExcludeType == "" &&
+		f.IncludeServer == "" && f.ExcludeServer == "" &&
+		len(f.Rename) == 0)
+}
+
+// Compile 检查并编译所有正则
+func (f *Filter) Compile() error {
+	_, err := f.compile()
+	return err
+}
+
+// compile 编译所有正则
+func (f *Filter) compile() (*compiledFilter, error) {
+	c := &compiledFilter{}
+
+	patterns := []struct {
+		field string
+		value string
+		dst   **regexp.Regexp
+	}{
+		{"include-name", f.IncludeName, &c.includeName},
+		{"exclude-name", f.ExcludeName, &c.excludeName},
+		{"include-type", f.IncludeType, &c.includeType},
+		{"exclude-type", f.ExcludeType, &c.excludeType},
+		{"include-server", f.IncludeServer, &c.includeServer},
+		{"exclude-server", f.ExcludeServer, &c.excludeServer},
+	}
+	for _, p := range patterns {
+		if p.value == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern: %w", p.field, err)
+		}
+		*p.dst = re
+	}
+
+	for _, rule := range f.Rename {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename pattern: %w", err)
+		}
+		c.rename = append(c.rename, re)
+		c.replace = append(c.replace, rule.Replace)
+	}
+
+	return c, nil
+}
+
+// ApplyFilter 对 Clash 配置内容执行节点过滤和重命名，并同步更新代理组和规则中的引用
+func ApplyFilter(data []byte, f *Filter) ([]byte, *FilterResult, error) {
+	if f.IsEmpty() {
+		return data, nil, nil
+	}
+
+	c, err := f.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	result := &FilterResult{}
+	renamed := make(map[string]string) // 旧名称 -> 新名称，空字符串表示被过滤
+	used := make(map[string]bool)
+
+	var kept []interface{}
+	for _, item := range listOf(cfg["proxies"]) {
+		proxy, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := proxy["name"].(string)
+
+		if !c.match(proxy) {
+			renamed[name] = ""
+			result.Removed++
+			continue
+		}
+
+		newName := c.applyRename(name)
+		for n := 2; used[newName]; n++ {
+			newName = fmt.Sprintf("%s %d", c.applyRename(name), n)
+		}
+		used[newName] = true
+		if newName != name {
+			proxy["name"] = newName
+			renamed[name] = newName
+			result.Renamed++
+		}
+
+		kept = append(kept, proxy)
+		result.Kept++
+	}
+	cfg["proxies"] = kept
+
+	fixGroupReferences(cfg, renamed)
+	result.Pruned = fixRuleReferences(cfg, renamed)
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	logger.Debug().
+		Int("kept", result.Kept).
+		Int("removed", result.Removed).
+		Int("renamed", result.Renamed).
+		Int("pruned_rules", result.Pruned).
+		Msg("Profile filter applied")
+
+	return out, result, nil
+}
+
+// match 检查节点是否满足过滤条件
+func (c *compiledFilter) match(proxy map[string]interface{}) bool {
+	name, _ := proxy["name"].(string)
+	proxyType, _ := proxy["type"].(string)
+	server, _ := proxy["server"].(string)
+
+	checks := []struct {
+		include, exclude *regexp.Regexp
+		value            string
+	}{
+		{c.includeName, c.excludeName, name},
+		{c.includeType, c.excludeType, proxyType},
+		{c.includeServer, c.excludeServer, server},
+	}
+	for _, check := range checks {
+		if check.include != nil && !check.include.MatchString(check.value) {
+			return false
+		}
+		if check.exclude != nil && check.exclude.MatchString(check.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRename 依次应用所有重命名规则
+func (c *compiledFilter) applyRename(name string) string {
+	for i, re := range c.rename {
+		name = re.ReplaceAllString(name, c.replace[i])
+	}
+	return name
+}
+
+// fixGroupReferences 更新代理组成员：删除被过滤的节点，替换被重命名的节点。
+// 成员被全部过滤的代理组回退为 DIRECT，避免 mihomo 启动失败。
+func fixGroupReferences(cfg map[string]interface{}, renamed map[string]string) {
+	if len(renamed) == 0 {
+		return
+	}
+
+	for _, item := range listOf(cfg["proxy-groups"]) {
+		group, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		members := listOf(group["proxies"])
+		if members == nil {
+			continue
+		}
+
+		var updated []interface{}
+		for _, m := range members {
+			name, _ := m.(string)
+			newName, changed := renamed[name]
+			switch {
+			case !changed:
+				updated = append(updated, m)
+			case newName != "":
+				updated = append(updated, newName)
+			}
+		}
+
+		_, hasProviders := group["use"]
+		if len(updated) == 0 && !hasProviders {
+			updated = []interface{}{"DIRECT"}
+		}
+		group["proxies"] = updated
+	}
+}
+
+// fixRuleReferences 更新直接以节点为目标的规则：替换被重命名的节点，删除目标被过滤的规则。
+// 返回删除的规则数量
+func fixRuleReferences(cfg map[string]interface{}, renamed map[string]string) int {
+	rules := listOf(cfg["rules"])
+	if len(renamed) == 0 || rules == nil {
+		return 0
+	}
+
+	pruned := 0
+	updated := make([]interface{}, 0, len(rules))
+	for _, item := range rules {
+		raw, ok := item.(string)
+		if !ok {
+			updated = append(updated, item)
+			continue
+		}
+		rule, err := config.ParseRule(raw)
+		if err != nil {
+			updated = append(updated, item)
+			continue
+		}
+
+		newName, changed := renamed[rule.Target]
+		switch {
+		case !changed:
+			updated = append(updated, item)
+		case newName == "":
+			pruned++
+		default:
+			updated = append(updated, replaceRuleTarget(raw, rule.Target, newName))
+		}
+	}
+	cfg["rules"] = updated
+	return pruned
+}
+
+// replaceRuleTarget 替换规则的目标（目标之后可能还有 no-resolve 等参数）
+func replaceRuleTarget(rule, from, to string) string {
+	fields := strings.Split(rule, ",")
+	for i := len(fields) - 1; i >= 0; i-- {
+		if strings.TrimSpace(fields[i]) == from {
+			fields[i] = to
+			break
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// listOf 读取配置中的列表，类型不符时返回空
+func listOf(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
@@ -0,0 +1,55 @@
+package subscription
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+func TestApplyFilterFixesReferences(t *testing.T) {
+	data := []byte(`proxies:
+  - {name: HK 01, type: ss, server: hk.example.com, port: 443, cipher: aes-128-gcm, password: x}
+  - {name: US 01, type: ss, server: us.example.com, port: 443, cipher: aes-128-gcm, password: x}
+  - {name: Info, type: ss, server: info.example.com, port: 1, cipher: aes-128-gcm, password: x}
+proxy-groups:
+  - {name: PROXY, type: select, proxies: [HK 01, US 01, Info]}
+  - {name: Info only, type: select, proxies: [Info]}
+rules:
+  - DOMAIN-SUFFIX,hk.example,HK 01
+  - DOMAIN-SUFFIX,info.example,Info
+  - IP-CIDR,10.0.0.0/8,HK 01,no-resolve
+  - MATCH,PROXY
+`)
+	filter := &Filter{
+		ExcludeName: "^Info$",
+		Rename:      []RenameRule{{Pattern: "^HK", Replace: "Hong Kong"}},
+	}
+
+	out, result, err := ApplyFilter(data, filter)
+	if err != nil {
+		t.Fatalf("ApplyFilter: %v", err)
+	}
+	if result.Kept != 2 || result.Removed != 1 || result.Renamed != 1 || result.Pruned != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	cfg, err := config.Parse(out)
+	if err != nil {
+		t.Fatalf("parse filtered config: %v", err)
+	}
+	if got := cfg.ProxyGroups[0].Proxies; !reflect.DeepEqual(got, []string{"Hong Kong 01", "US 01"}) {
+		t.Errorf("PROXY members = %v", got)
+	}
+	if got := cfg.ProxyGroups[1].Proxies; !reflect.DeepEqual(got, []string{"DIRECT"}) {
+		t.Errorf("emptied group members = %v", got)
+	}
+	want := []string{
+		"DOMAIN-SUFFIX,hk.example,Hong Kong 01",
+		"IP-CIDR,10.0.0.0/8,Hong Kong 01,no-resolve",
+		"MATCH,PROXY",
+	}
+	if !reflect.DeepEqual(cfg.Rules, want) {
+		t.Errorf("rules = %v, want %v", cfg.Rules, want)
+	}
+}
@@ -0,0 +1,169 @@
+package subscription
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clash-fish/clash-fish/pkg/utils"
+)
+
+const (
+	// historyDirName 历史版本目录名
+	historyDirName = "history"
+
+	// DefaultHistoryLimit 默认保留的历史版本数
+	DefaultHistoryLimit = 10
+)
+
+// Revision profile 历史版本
+type Revision struct {
+	Rev     int
+	SavedAt time.Time
+	Path    string
+}
+
+// History 列出 profile 的历史版本，按版本号从新到旧排序
+func (s *Store) History(name string) ([]Revision, error) {
+	if !s.Exists(name) {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+
+	entries, err := os.ReadDir(s.GetHistoryDir(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var revisions []Revision
+	for _, entry := range entries {
+		rev, ok := parseRevisionFile(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		revisions = append(revisions, Revision{
+			Rev:     rev,
+			SavedAt: info.ModTime(),
+			Path:    filepath.Join(s.GetHistoryDir(name), entry.Name()),
+		})
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Rev > revisions[j].Rev
+	})
+	return revisions, nil
+}
+
+// ReadRevision 读取指定历史版本的内容
+func (s *Store) ReadRevision(name string, rev int) ([]byte, error) {
+	if !s.Exists(name) {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+
+	data, err := os.ReadFile(s.revisionPath(name, rev))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("revision %d of profile '%s' not found", rev, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision: %w", err)
+	}
+	return data, nil
+}
+
+// Rollback 将 profile 恢复到指定历史版本，当前内容会被归档为新的历史版本
+func (s *Store) Rollback(name string, rev int) (*Profile, error) {
+	profile, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.ReadRevision(name, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Save(profile, data); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// GetHistoryDir 获取 profile 历史版本目录
+func (s *Store) GetHistoryDir(name string) string {
+	return filepath.Join(s.GetProfileDir(name), historyDirName)
+}
+
+// archive 内容发生变化时将当前内容归档为新的历史版本
+func (s *Store) archive(profile *Profile, next []byte) error {
+	current, err := os.ReadFile(s.GetProfilePath(profile.Name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read current profile: %w", err)
+	}
+	if hashContent(current) == hashContent(next) {
+		return nil
+	}
+
+	revisions, err := s.History(profile.Name)
+	if err != nil {
+		return err
+	}
+	rev := 1
+	if len(revisions) > 0 {
+		rev = revisions[0].Rev + 1
+	}
+
+	if err := os.MkdirAll(s.GetHistoryDir(profile.Name), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := utils.WriteFileAtomic(s.revisionPath(profile.Name, rev), current, utils.PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write revision: %w", err)
+	}
+
+	return s.pruneHistory(profile, append([]Revision{{Rev: rev}}, revisions...))
+}
+
+// pruneHistory 删除超出保留数量的旧版本
+func (s *Store) pruneHistory(profile *Profile, revisions []Revision) error {
+	limit := profile.HistoryLimit
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+
+	for i := limit; i < len(revisions); i++ {
+		if err := os.Remove(s.revisionPath(profile.Name, revisions[i].Rev)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old revision: %w", err)
+		}
+	}
+	return nil
+}
+
+// revisionPath 历史版本文件路径
+func (s *Store) revisionPath(name string, rev int) string {
+	return filepath.Join(s.GetHistoryDir(name), fmt.Sprintf("%d.yaml", rev))
+}
+
+// parseRevisionFile 从文件名解析版本号
+func parseRevisionFile(name string) (int, bool) {
+	base, ok := strings.CutSuffix(name, ".yaml")
+	if !ok {
+		return 0, false
+	}
+	rev, err := strconv.Atoi(base)
+	if err != nil || rev <= 0 {
+		return 0, false
+	}
+	return rev, true
+}
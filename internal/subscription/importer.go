@@ -0,0 +1,469 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"github.com/clash-fish/clash-fish/pkg/constants"
+	"github.com/clash-fish/clash-fish/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultTimeout 下载订阅的默认超时时间
+	defaultTimeout = 30 * time.Second
+
+	// maxContentSize 订阅内容大小上限
+	maxContentSize = 16 << 20
+)
+
+var (
+	// ErrUnsupportedFormat 订阅内容不是可识别的配置格式
+	ErrUnsupportedFormat = errors.New("unsupported subscription format")
+
+	// ErrNotRereadable profile 内容来自标准输入，无法重新读取
+	ErrNotRereadable = errors.New("profile content cannot be re-read")
+)
+
+// FetchRequest 订阅下载请求
+type FetchRequest struct {
+	URL string
+
+	// 条件请求：内容未变化时服务端返回 304
+	ETag         string
+	LastModified string
+
+	// 自定义请求：为空时使用默认 User-Agent 并直接连接
+	UserAgent string
+	Headers   map[string]string
+	Proxy     *url.URL
+}
+
+// FetchResult 订阅下载结果
+type FetchResult struct {
+	Data         []byte
+	ETag         string
+	LastModified string
+	NotModified  bool
+	UserInfo     *UserInfo
+}
+
+// Downloader 订阅下载器
+type Downloader struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewDownloader 创建订阅下载器，client 为 nil 时使用默认 HTTP 客户端
+func NewDownloader(client *http.Client) *Downloader {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Downloader{
+		client:    client,
+		userAgent: fmt.Sprintf("%s/%s", constants.AppName, constants.Version),
+	}
+}
+
+// Fetch 下载订阅内容
+func (d *Downloader) Fetch(ctx context.Context, fr *FetchRequest) (*FetchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fr.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription url: %w", err)
+	}
+	for key, value := range fr.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("User-Agent", firstNonEmpty(fr.UserAgent, d.userAgent))
+	if fr.ETag != "" {
+		req.Header.Set("If-None-Match", fr.ETag)
+	}
+	if fr.LastModified != "" {
+		req.Header.Set("If-Modified-Since", fr.LastModified)
+	}
+
+	resp, err := d.clientFor(fr.Proxy).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			NotModified:  true,
+		}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxContentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subscription: %w", err)
+	}
+	if len(data) > maxContentSize {
+		return nil, fmt.Errorf("subscription is too large (limit %d bytes)", maxContentSize)
+	}
+
+	result := &FetchResult{
+		Data:         data,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	// 流量信息解析失败不影响订阅本身
+	if header := resp.Header.Get(UserInfoHeader); header != "" {
+		info, err := ParseUserInfo(header)
+		if err != nil {
+			logger.Warn().Err(err).Str("url", fr.URL).Msg("Failed to parse subscription userinfo")
+		} else {
+			result.UserInfo = info
+		}
+	}
+
+	return result, nil
+}
+
+// clientFor 获取使用指定代理的 HTTP 客户端，proxy 为 nil 时直接连接
+func (d *Downloader) clientFor(proxy *url.URL) *http.Client {
+	if proxy == nil {
+		return d.client
+	}
+
+	base, ok := d.client.Transport.(*http.Transport)
+	if !ok || base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+
+	client := *d.client
+	client.Transport = transport
+	return &client
+}
+
+// Importer 订阅导入器，负责下载、校验并保存到 profile 存储
+type Importer struct {
+	store      *Store
+	downloader *Downloader
+	localProxy string
+	validate   func(data []byte) error
+}
+
+// NewImporter 创建订阅导入器，默认直接校验 profile 内容
+func NewImporter(store *Store, downloader *Downloader) *Importer {
+	return &Importer{
+		store:      store,
+		downloader: downloader,
+		validate:   validateContent,
+	}
+}
+
+// SetValidator 设置保存 profile 前的校验函数，如检查叠加本地覆盖层后的引用关系
+func (i *Importer) SetValidator(validate func(data []byte) error) {
+	i.validate = validate
+}
+
+// SetLocalProxy 设置本地 HTTP 代理地址，供 via-proxy 的 profile 下载使用
+func (i *Importer) SetLocalProxy(addr string) {
+	i.localProxy = addr
+}
+
+// Add 添加新的订阅 profile，调用方需设置 Name 以及 URL 或 Sources
+func (i *Importer) Add(ctx context.Context, profile *Profile) error {
+	if err := ValidateName(profile.Name); err != nil {
+		return err
+	}
+	if i.store.Exists(profile.Name) {
+		return fmt.Errorf("profile '%s' already exists", profile.Name)
+	}
+	if profile.IsAggregate() {
+		if err := i.validateSources(profile); err != nil {
+			return err
+		}
+	}
+	if profile.Filter != nil {
+		if err := profile.Filter.Compile(); err != nil {
+			return err
+		}
+	}
+
+	if profile.Kind() == "stdin" {
+		return fmt.Errorf("profile '%s' has no url, file or sources", profile.Name)
+	}
+
+	if _, err := i.fetch(ctx, profile, false); err != nil {
+		return err
+	}
+
+	logger.Info().Str("name", profile.Name).Str("hash", profile.Hash).Msg("Profile saved")
+	return nil
+}
+
+// AddContent 以给定内容添加 profile（如从标准输入读取），这类 profile 无法重新读取
+func (i *Importer) AddContent(profile *Profile, raw []byte) error {
+	if err := ValidateName(profile.Name); err != nil {
+		return err
+	}
+	if i.store.Exists(profile.Name) {
+		return fmt.Errorf("profile '%s' already exists", profile.Name)
+	}
+	if profile.Filter != nil {
+		if err := profile.Filter.Compile(); err != nil {
+			return err
+		}
+	}
+
+	data, err := Convert(raw)
+	if err != nil {
+		return err
+	}
+
+	profile.UpdatedAt = time.Now()
+	if _, err := i.save(profile, data, false); err != nil {
+		return err
+	}
+
+	logger.Info().Str("name", profile.Name).Str("hash", profile.Hash).Msg("Profile saved")
+	return nil
+}
+
+// Update 强制重新下载指定 profile
+func (i *Importer) Update(ctx context.Context, name string) (*Profile, error) {
+	profile, err := i.store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := i.fetch(ctx, profile, false); err != nil {
+		return nil, err
+	}
+
+	logger.Info().Str("name", name).Str("hash", profile.Hash).Msg("Profile updated")
+	return profile, nil
+}
+
+// Refresh 使用条件请求刷新 profile，返回内容是否发生变化。
+// 新内容无法通过校验时保留旧内容。
+func (i *Importer) Refresh(ctx context.Context, name string) (bool, error) {
+	profile, err := i.store.Get(name)
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := i.fetch(ctx, profile, true)
+	if err != nil {
+		return false, err
+	}
+
+	if changed {
+		logger.Info().Str("name", name).Str("hash", profile.Hash).Msg("Profile refreshed")
+	} else {
+		logger.Debug().Str("name", name).Msg("Profile not modified")
+	}
+	return changed, nil
+}
+
+// fetch 下载（或读取本地文件）、校验并保存 profile，返回内容是否发生变化
+func (i *Importer) fetch(ctx context.Context, profile *Profile, conditional bool) (bool, error) {
+	var data []byte
+	var err error
+
+	switch profile.Kind() {
+	case "aggregate":
+		return i.fetchAggregate(ctx, profile, !conditional)
+	case "stdin":
+		return false, fmt.Errorf("%w: profile '%s' was imported from stdin", ErrNotRereadable, profile.Name)
+	case "file":
+		data, err = readLocalFile(profile.File)
+		profile.UpdatedAt = time.Now()
+	default:
+		urls := []string{profile.URL}
+		if profile.Fetch != nil {
+			urls = append(urls, profile.Fetch.Mirrors...)
+		}
+		data, err = i.download(ctx, urls, profile.Fetch, &profile.FetchState, conditional)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// 内容未变化，只更新时间戳
+	if data == nil {
+		return false, i.store.UpdateState(profile)
+	}
+
+	return i.save(profile, data, conditional)
+}
+
+// save 过滤、校验并保存 profile 内容，返回内容是否发生变化
+func (i *Importer) save(profile *Profile, data []byte, conditional bool) (bool, error) {
+	// 写入前过滤和重命名节点
+	data, _, err := ApplyFilter(data, profile.Filter)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := ParseContent(data); err != nil {
+		return false, err
+	}
+	if err := i.check(profile, data, conditional); err != nil {
+		return false, err
+	}
+
+	changed := hashContent(data) != profile.Hash
+	if err := i.store.Save(profile, data); err != nil {
+		return false, err
+	}
+
+	WarnUsage(profile)
+	return changed, nil
+}
+
+// download 依次尝试 urls 下载订阅并转换为 Clash 配置，同时更新下载状态（不含 Hash）。
+// 条件请求返回 304 时数据为 nil。
+func (i *Importer) download(ctx context.Context, urls []string, opts *FetchOptions, state *FetchState, conditional bool) ([]byte, error) {
+	req := &FetchRequest{}
+	if conditional {
+		req.ETag = state.ETag
+		req.LastModified = state.LastModified
+	}
+
+	result, err := i.fetchWithRetry(ctx, urls, opts, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.UserInfo != nil {
+		state.UserInfo = result.UserInfo
+	}
+	state.UpdatedAt = time.Now()
+
+	if result.NotModified {
+		return nil, nil
+	}
+
+	// 分享链接等格式统一转换为 Clash 配置
+	data, err := Convert(result.Data)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckSecretRefs(data); err != nil {
+		return nil, fmt.Errorf("subscription rejected: %w", err)
+	}
+
+	state.ETag = result.ETag
+	state.LastModified = result.LastModified
+	return data, nil
+}
+
+// CheckSecretRefs 拒绝外来内容（订阅、导出包）中的密钥引用。引用只应出现在用户自己编写的配置中，
+// 否则外来内容可以借 file: 或 env: 读取本机的文件和环境变量并发送到它指定的服务器
+func CheckSecretRefs(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var refs []string
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if config.SensitiveKeys[key.Value] && value.Kind == yaml.ScalarNode && config.IsSecretRef(value.Value) {
+					refs = append(refs, fmt.Sprintf("%s: %s", key.Value, value.Value))
+				}
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(&doc)
+
+	if len(refs) > 0 {
+		return fmt.Errorf("secret references are only allowed in local configuration: %s", strings.Join(refs, ", "))
+	}
+	return nil
+}
+
+// readLocalFile 读取本地配置文件并转换为 Clash 配置
+func readLocalFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.Size() > maxContentSize {
+		return nil, fmt.Errorf("file is too large (limit %d bytes)", maxContentSize)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return Convert(raw)
+}
+
+// check 校验待保存的 profile 内容，无效内容不会替换已保存的版本
+func (i *Importer) check(profile *Profile, data []byte, conditional bool) error {
+	err := i.validate(data)
+	switch {
+	case err == nil:
+		return nil
+	case conditional:
+		return fmt.Errorf("refreshed profile is invalid, keeping current version: %w", err)
+	default:
+		return fmt.Errorf("profile '%s' is invalid: %w", profile.Name, err)
+	}
+}
+
+// validateContent 默认校验：profile 内容能否解析、引用关系是否完整，
+// 完整校验在应用 profile 时进行
+func validateContent(data []byte) error {
+	return config.ValidateReferences(data)
+}
+
+// WarnUsage 流量即将用尽或订阅即将到期时记录告警日志
+func WarnUsage(profile *Profile) {
+	now := time.Now()
+	if profile.UserInfo != nil {
+		for _, warning := range profile.UserInfo.Warnings(now) {
+			logger.Warn().Str("name", profile.Name).Msg(warning)
+		}
+	}
+
+	for _, src := range profile.Sources {
+		if src.UserInfo == nil {
+			continue
+		}
+		for _, warning := range src.UserInfo.Warnings(now) {
+			logger.Warn().Str("name", profile.Name).Str("source", src.Name).Msg(warning)
+		}
+	}
+}
+
+// ParseContent 校验订阅内容能否解析为 Clash 配置
+func ParseContent(data []byte) (*config.Config, error) {
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	if len(cfg.Proxies) == 0 && len(cfg.ProxyGroups) == 0 {
+		return nil, fmt.Errorf("%w: no proxies or proxy-groups found", ErrUnsupportedFormat)
+	}
+
+	return cfg, nil
+}
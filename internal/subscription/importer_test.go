@@ -0,0 +1,122 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+)
+
+func TestAddRejectsRemoteSecretRefs(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("local secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	content := strings.Replace(testSubscription, "password: secret", "password: file:"+secretFile, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		profile *Profile
+	}{
+		{"url", &Profile{Name: "remote", URL: server.URL}},
+		{"aggregate", &Profile{Name: "merged", Sources: []*Source{{Name: "remote", URL: server.URL}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestImporter(t)
+			err := i.Add(context.Background(), tt.profile)
+			if err == nil || !strings.Contains(err.Error(), "secret references") {
+				t.Fatalf("expected secret reference error, got %v", err)
+			}
+			if i.store.Exists(tt.profile.Name) {
+				t.Fatal("profile with a remote secret reference was saved")
+			}
+		})
+	}
+}
+
+func TestAddKeepsLocalSecretRefs(t *testing.T) {
+	// 本地文件由用户自己编写，引用保持原样，等生成实际配置时再解析
+	path := filepath.Join(t.TempDir(), "local.yaml")
+	content := strings.Replace(testSubscription, "password: secret", "password: env:HK_PASSWORD", 1)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	i := newTestImporter(t)
+	i.SetValidator(func([]byte) error { return nil })
+	if err := i.Add(context.Background(), &Profile{Name: "local", File: path}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	data, err := i.store.ReadConfig("local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "env:HK_PASSWORD") {
+		t.Fatalf("secret reference not kept:\n%s", data)
+	}
+}
+
+func TestAddChecksOnlyReferences(t *testing.T) {
+	// 只有 mixed-port 没有 port 的普通订阅，端口等设置在应用时才完整校验
+	const mixedPortOnly = `mixed-port: 7890
+mode: rule
+proxies:
+  - name: HK
+    type: ss
+    server: 1.2.3.4
+    port: 8388
+    cipher: aes-128-gcm
+    password: secret
+proxy-groups:
+  - name: Proxy
+    type: select
+    proxies: [HK, DIRECT]
+rules:
+  - MATCH,Proxy
+`
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"mixed-port only", mixedPortOnly, ""},
+		{"unknown rule target", strings.Replace(mixedPortOnly, "MATCH,Proxy", "MATCH,Missing", 1), `rule target "Missing"`},
+		{"unknown group member", strings.Replace(mixedPortOnly, "[HK, DIRECT]", "[JP, DIRECT]", 1), `unknown proxy or group "JP"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sub.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			i := newTestImporter(t)
+			i.SetValidator(config.NewManager(t.TempDir()).ValidateReferences)
+			err := i.Add(context.Background(), &Profile{Name: "sub", File: path})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Add: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if i.store.Exists("sub") {
+				t.Fatal("profile with broken references was saved")
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package subscription
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// FetchState 远程内容的下载状态
+type FetchState struct {
+	UpdatedAt    time.Time `yaml:"updated-at"`
+	ETag         string    `yaml:"etag,omitempty"`
+	LastModified string    `yaml:"last-modified,omitempty"`
+	Hash         string    `yaml:"hash,omitempty"`
+	UserInfo     *UserInfo `yaml:"userinfo,omitempty"`
+}
+
+// Profile 订阅配置元数据
+type Profile struct {
+	Name       string `yaml:"name"`
+	URL        string `yaml:"url,omitempty"`
+	File       string `yaml:"file,omitempty"`     // 本地文件，与 URL 互斥；两者都为空且无来源时表示从标准输入导入
+	Interval   int    `yaml:"interval,omitempty"` // 自动更新间隔（秒），0 表示不自动更新
+	FetchState `yaml:",inline"`
+
+	HistoryLimit int           `yaml:"history-limit,omitempty"` // 保留的历史版本数，0 表示使用默认值
+	Sources      []*Source     `yaml:"sources,omitempty"`       // 聚合 profile 的来源列表
+	Filter       *Filter       `yaml:"filter,omitempty"`        // 导入时的节点过滤与重命名
+	Fetch        *FetchOptions `yaml:"fetch,omitempty"`         // 下载选项
+}
+
+// Source 聚合 profile 的单个来源，三种类型互斥
+type Source struct {
+	Name       string `yaml:"name"`
+	URL        string `yaml:"url,omitempty"`      // 订阅链接
+	File       string `yaml:"file,omitempty"`     // 本地文件
+	Profile    string `yaml:"profile,omitempty"`  // 其他 profile
+	Interval   int    `yaml:"interval,omitempty"` // 自动更新间隔（秒），仅对订阅链接有效
+	FetchState `yaml:",inline"`
+}
+
+// IsAggregate 检查是否是由多个来源聚合的 profile
+func (p *Profile) IsAggregate() bool {
+	return len(p.Sources) > 0
+}
+
+// Kind profile 类型：url / file / stdin / aggregate
+func (p *Profile) Kind() string {
+	switch {
+	case p.IsAggregate():
+		return "aggregate"
+	case p.URL != "":
+		return "url"
+	case p.File != "":
+		return "file"
+	default:
+		return "stdin"
+	}
+}
+
+// Location profile 内容位置（链接、文件路径或 stdin）
+func (p *Profile) Location() string {
+	return firstNonEmpty(p.URL, p.File, "stdin")
+}
+
+// GetInterval 获取自动更新间隔
+func (p *Profile) GetInterval() time.Duration {
+	return time.Duration(p.Interval) * time.Second
+}
+
+// NextUpdate 下次自动更新时间，未开启自动更新时返回零值
+func (p *Profile) NextUpdate() time.Time {
+	return nextUpdate(p.UpdatedAt, p.Interval)
+}
+
+// IsDue 检查 profile 是否需要自动更新
+func (p *Profile) IsDue(now time.Time) bool {
+	return isDue(p.NextUpdate(), now)
+}
+
+// Kind 来源类型：url / file / profile
+func (s *Source) Kind() string {
+	switch {
+	case s.URL != "":
+		return "url"
+	case s.File != "":
+		return "file"
+	default:
+		return "profile"
+	}
+}
+
+// Location 来源位置（链接、文件路径或 profile 名称）
+func (s *Source) Location() string {
+	return firstNonEmpty(s.URL, s.File, s.Profile)
+}
+
+// NextUpdate 下次自动更新时间，未开启自动更新时返回零值
+func (s *Source) NextUpdate() time.Time {
+	return nextUpdate(s.UpdatedAt, s.Interval)
+}
+
+// IsDue 检查来源是否需要重新下载
+func (s *Source) IsDue(now time.Time) bool {
+	return isDue(s.NextUpdate(), now)
+}
+
+// nextUpdate 根据上次更新时间和间隔计算下次更新时间
+func nextUpdate(updatedAt time.Time, interval int) time.Time {
+	if interval <= 0 {
+		return time.Time{}
+	}
+	return updatedAt.Add(time.Duration(interval) * time.Second)
+}
+
+// isDue 检查是否已到更新时间
+func isDue(next, now time.Time) bool {
+	return !next.IsZero() && !now.Before(next)
+}
+
+// index profiles 元数据索引文件结构
+type index struct {
+	Active   string     `yaml:"active,omitempty"`
+	Profiles []*Profile `yaml:"profiles"`
+}
+
+// validName profile 名称只允许字母、数字、点、下划线和连字符
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// ValidateName 检查 profile 名称是否合法（名称会作为目录名使用）
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (allowed: letters, digits, '.', '_', '-')", name)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package subscription
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue 脱敏后的占位值
+const RedactedValue = "REDACTED"
+
+// tokenSegment 看起来像订阅令牌的路径片段
+var tokenSegment = regexp.MustCompile(`^[A-Za-z0-9_\-]{16,}$`)
+
+// RedactConfig 脱敏配置内容中的密码、UUID 和订阅链接令牌，保留原有字段顺序和注释
+func RedactConfig(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if doc.Kind == 0 {
+		return data, nil
+	}
+
+	redactNode(&doc)
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out, nil
+}
+
+// redactNode 递归脱敏 YAML 节点
+func redactNode(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Value != "" {
+				switch {
+				case config.SensitiveKeys[key.Value] && config.IsSecretRef(value.Value):
+					// 密钥引用不含实际值，保留以便导入后继续使用
+					continue
+				case config.SensitiveKeys[key.Value]:
+					value.Value = RedactedValue
+					value.Tag = "!!str"
+					value.Style = 0
+					continue
+				case key.Value == "url":
+					value.Value = RedactURL(value.Value)
+					continue
+				}
+			}
+			redactNode(value)
+		}
+		return
+	}
+
+	for _, child := range node.Content {
+		redactNode(child)
+	}
+}
+
+// RedactURL 脱敏订阅链接：隐藏用户信息、查询参数值和疑似令牌的路径片段
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	if u.User != nil {
+		u.User = url.User(RedactedValue)
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query.Set(key, RedactedValue)
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, seg := range segments {
+		if tokenSegment.MatchString(seg) {
+			segments[i] = RedactedValue
+		}
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+
+	return u.String()
+}
+
+// IsRedactedURL 检查链接是否经过脱敏（无法用于下载）
+func IsRedactedURL(raw string) bool {
+	return strings.Contains(raw, RedactedValue)
+}
+
+// RedactProfile 脱敏 profile 元数据中的订阅链接和自定义请求头
+func RedactProfile(profile *Profile) {
+	profile.URL = RedactURL(profile.URL)
+	for _, src := range profile.Sources {
+		src.URL = RedactURL(src.URL)
+	}
+
+	if profile.Fetch != nil {
+		fetch := *profile.Fetch
+		fetch.Mirrors = nil
+		for _, mirror := range profile.Fetch.Mirrors {
+			fetch.Mirrors = append(fetch.Mirrors, RedactURL(mirror))
+		}
+		fetch.Headers = nil
+		for key := range profile.Fetch.Headers {
+			if fetch.Headers == nil {
+				fetch.Headers = make(map[string]string)
+			}
+			fetch.Headers[key] = RedactedValue
+		}
+		profile.Fetch = &fetch
+	}
+}
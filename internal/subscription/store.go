@@ -0,0 +1,395 @@
+package subscription
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clash-fish/clash-fish/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// indexFileName 元数据索引文件名
+	indexFileName = "index.yaml"
+
+	// profileFileName 每个 profile 目录下的配置文件名
+	profileFileName = "config.yaml"
+
+	// sourcesDirName 聚合 profile 来源缓存目录名（旧版本位于 profile 目录下）
+	sourcesDirName = "sources"
+)
+
+// ErrProfileNotFound profile 不存在
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Store profile 存储，布局如下：
+//
+//	profiles/
+//	├── index.yaml          # 元数据索引
+//	└── <name>/
+//	    ├── config.yaml     # 订阅下载的配置（聚合 profile 为合并结果）
+//	    └── history/        # 内容变化前的历史版本
+//	        └── <rev>.yaml
+//	cache/sources/
+//	└── <name>/             # 聚合 profile 各订阅来源未经过滤的下载缓存
+//	    └── <source>.yaml
+type Store struct {
+	dir       string
+	indexPath string
+	cacheDir  string
+}
+
+// NewStore 创建 profile 存储，来源缓存位于同级的 cache 目录
+func NewStore(dir string) *Store {
+	return &Store{
+		dir:       dir,
+		indexPath: filepath.Join(dir, indexFileName),
+		cacheDir:  filepath.Join(filepath.Dir(dir), "cache", sourcesDirName),
+	}
+}
+
+// List 列出所有 profile
+func (s *Store) List() ([]*Profile, error) {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	return idx.Profiles, nil
+}
+
+// Get 获取指定 profile 的元数据
+func (s *Store) Get(name string) (*Profile, error) {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range idx.Profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+}
+
+// Exists 检查 profile 是否存在
+func (s *Store) Exists(name string) bool {
+	_, err := s.Get(name)
+	return err == nil
+}
+
+// GetActive 获取当前激活的 profile 名称
+func (s *Store) GetActive() (string, error) {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return "", err
+	}
+	return idx.Active, nil
+}
+
+// SetActive 设置当前激活的 profile
+func (s *Store) SetActive(name string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	idx, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	if name != "" {
+		found := false
+		for _, p := range idx.Profiles {
+			if p.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+		}
+	}
+
+	idx.Active = name
+	return s.saveIndex(idx)
+}
+
+// Save 保存 profile 配置内容并更新元数据索引。
+// profile 已存在时只写入下载状态，不覆盖其他进程同时修改的设置
+func (s *Store) Save(profile *Profile, data []byte) error {
+	if err := ValidateName(profile.Name); err != nil {
+		return err
+	}
+
+	// 写入配置内容，旧内容归档到历史版本
+	if err := os.MkdirAll(s.GetProfileDir(profile.Name), 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.archive(profile, data); err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(s.GetProfilePath(profile.Name), data, utils.PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+
+	profile.Hash = hashContent(data)
+
+	return s.updateMeta(profile)
+}
+
+// UpdateState 只更新 profile 的下载状态（时间戳、ETag、流量信息等），不改动配置内容和设置
+func (s *Store) UpdateState(profile *Profile) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.updateMeta(profile)
+}
+
+// Modify 持有锁时重新读取 profile 并交给 modify 修改设置，返回修改后的 profile。
+// modify 返回错误时索引保持不变
+func (s *Store) Modify(name string, modify func(profile *Profile) error) (*Profile, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	idx, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range idx.Profiles {
+		if p.Name != name {
+			continue
+		}
+		if err := modify(p); err != nil {
+			return nil, err
+		}
+		if err := s.saveIndex(idx); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+}
+
+// updateMeta 写入 profile 元数据，调用方需持有锁。
+// 索引中已有同名 profile 时只合并下载状态：调用方的 profile 在加锁前读取，
+// 其中的设置可能已被其他进程（如 profile set）修改
+func (s *Store) updateMeta(profile *Profile) error {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range idx.Profiles {
+		if p.Name == profile.Name {
+			mergeState(p, profile)
+			return s.saveIndex(idx)
+		}
+	}
+
+	idx.Profiles = append(idx.Profiles, profile)
+	return s.saveIndex(idx)
+}
+
+// mergeState 将 from 的下载状态合并到 to，聚合来源按名称匹配
+func mergeState(to, from *Profile) {
+	to.FetchState = from.FetchState
+	for _, src := range to.Sources {
+		for _, fromSrc := range from.Sources {
+			if fromSrc.Name == src.Name {
+				src.FetchState = fromSrc.FetchState
+				break
+			}
+		}
+	}
+}
+
+// ReadConfig 读取 profile 的配置内容
+func (s *Store) ReadConfig(name string) ([]byte, error) {
+	if !s.Exists(name) {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+
+	data, err := os.ReadFile(s.GetProfilePath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	return data, nil
+}
+
+// ReadSource 读取聚合 profile 中订阅来源的缓存内容
+func (s *Store) ReadSource(name, source string) ([]byte, error) {
+	data, err := os.ReadFile(s.GetSourcePath(name, source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source cache: %w", err)
+	}
+	return data, nil
+}
+
+// WriteSource 写入聚合 profile 中订阅来源的缓存内容
+func (s *Store) WriteSource(name, source string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.GetSourcePath(name, source)), 0755); err != nil {
+		return fmt.Errorf("failed to create sources directory: %w", err)
+	}
+	if err := utils.WriteFileAtomic(s.GetSourcePath(name, source), data, utils.PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write source cache: %w", err)
+	}
+
+	// 旧版本把缓存放在 profile 目录下
+	if err := os.RemoveAll(filepath.Join(s.GetProfileDir(name), sourcesDirName)); err != nil {
+		return fmt.Errorf("failed to remove legacy source cache: %w", err)
+	}
+	return nil
+}
+
+// ReferencedBy 列出以指定 profile 作为来源的聚合 profile
+func (s *Store) ReferencedBy(name string) ([]string, error) {
+	idx, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, p := range idx.Profiles {
+		for _, src := range p.Sources {
+			if src.Profile == name {
+				names = append(names, p.Name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+// Delete 删除 profile 及其文件
+func (s *Store) Delete(name string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	idx, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	// 被聚合 profile 引用时不允许删除
+	refs, err := s.ReferencedBy(name)
+	if err != nil {
+		return err
+	}
+	if len(refs) > 0 {
+		return fmt.Errorf("profile '%s' is used as a source by: %s", name, strings.Join(refs, ", "))
+	}
+
+	found := false
+	profiles := idx.Profiles[:0]
+	for _, p := range idx.Profiles {
+		if p.Name == name {
+			found = true
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	idx.Profiles = profiles
+
+	if err := os.RemoveAll(s.GetProfileDir(name)); err != nil {
+		return fmt.Errorf("failed to remove profile directory: %w", err)
+	}
+	if err := os.RemoveAll(filepath.Join(s.cacheDir, name)); err != nil {
+		return fmt.Errorf("failed to remove source cache: %w", err)
+	}
+
+	return s.saveIndex(idx)
+}
+
+// GetDir 获取 profiles 目录路径
+func (s *Store) GetDir() string {
+	return s.dir
+}
+
+// GetProfileDir 获取 profile 目录路径
+func (s *Store) GetProfileDir(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// GetProfilePath 获取 profile 配置文件路径
+func (s *Store) GetProfilePath(name string) string {
+	return filepath.Join(s.dir, name, profileFileName)
+}
+
+// GetSourcePath 获取聚合 profile 来源缓存文件路径
+func (s *Store) GetSourcePath(name, source string) string {
+	return filepath.Join(s.cacheDir, name, source+".yaml")
+}
+
+// loadIndex 读取元数据索引，文件不存在时返回空索引
+func (s *Store) loadIndex() (*index, error) {
+	data, err := os.ReadFile(s.indexPath)
+	if os.IsNotExist(err) {
+		return &index{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile index: %w", err)
+	}
+
+	var idx index
+	if err := yaml.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse profile index: %w", err)
+	}
+	return &idx, nil
+}
+
+// saveIndex 写入元数据索引
+func (s *Store) saveIndex(idx *index) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile index: %w", err)
+	}
+
+	// 索引中的订阅地址通常带有 token
+	if err := utils.WriteFileAtomic(s.indexPath, data, utils.PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write profile index: %w", err)
+	}
+	return nil
+}
+
+// lock 对元数据索引加锁，串行化 CLI 和后台服务对 profile 的修改
+func (s *Store) lock() (func(), error) {
+	return utils.LockFile(s.indexPath)
+}
+
+// hashContent 计算配置内容的 SHA-256 摘要
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
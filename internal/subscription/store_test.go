@@ -0,0 +1,122 @@
+package subscription
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// newTestStore 创建包含一个订阅 profile 的存储
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store := NewStore(t.TempDir())
+	profile := &Profile{
+		Name:     "home",
+		Interval: 3600,
+		Sources:  []*Source{{Name: "a", URL: "https://a.example.com/sub"}},
+	}
+	if err := store.Save(profile, []byte(testSubscription)); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestUpdateStateKeepsSettings(t *testing.T) {
+	store := newTestStore(t)
+
+	// 刷新开始时读取的 profile
+	stale, err := store.Get("home")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 刷新期间另一个进程修改了设置
+	if _, err := store.Modify("home", func(p *Profile) error {
+		p.Interval = 600
+		p.Sources[0].Interval = 300
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stale.ETag = `"v2"`
+	stale.Sources[0].ETag = `"a2"`
+	if err := store.UpdateState(stale); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(stale, []byte(testSubscription+"# v2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Get("home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Interval != 600 || got.Sources[0].Interval != 300 {
+		t.Errorf("refresh undid settings: interval=%d source interval=%d", got.Interval, got.Sources[0].Interval)
+	}
+	if got.ETag != `"v2"` || got.Sources[0].ETag != `"a2"` || got.Hash != stale.Hash {
+		t.Errorf("fetch state not saved: %+v", got.FetchState)
+	}
+}
+
+func TestModifyRejectsError(t *testing.T) {
+	store := newTestStore(t)
+	errTest := errors.New("invalid interval")
+	_, err := store.Modify("home", func(p *Profile) error {
+		p.Interval = 1
+		return errTest
+	})
+	if err != errTest {
+		t.Fatalf("Modify error = %v, want %v", err, errTest)
+	}
+	if got, _ := store.Get("home"); got.Interval != 3600 {
+		t.Errorf("failed modify was saved: interval=%d", got.Interval)
+	}
+	if _, err := store.Modify("missing", func(*Profile) error { return nil }); err == nil {
+		t.Error("expected error for missing profile")
+	}
+}
+
+func TestConcurrentModifyAndUpdateState(t *testing.T) {
+	store := newTestStore(t)
+	const n = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Modify("home", func(p *Profile) error {
+				p.HistoryLimit++
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			stale, err := store.Get("home")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			stale.ETag = "refreshed"
+			if err := store.UpdateState(stale); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := store.Get("home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HistoryLimit != n {
+		t.Errorf("history limit = %d, want %d: concurrent writes were lost", got.HistoryLimit, n)
+	}
+	if got.ETag != "refreshed" {
+		t.Errorf("etag = %q, want refreshed", got.ETag)
+	}
+}
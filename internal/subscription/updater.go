@@ -0,0 +1,97 @@
+package subscription
+
+import (
+	"context"
+	"time"
+
+	"github.com/clash-fish/clash-fish/pkg/logger"
+)
+
+const (
+	// checkInterval 检查是否有 profile 需要更新的周期
+	checkInterval = time.Minute
+
+	// retryDelay 自动更新失败后的重试间隔
+	retryDelay = 10 * time.Minute
+)
+
+// Updater 后台定时更新订阅
+type Updater struct {
+	store    *Store
+	importer *Importer
+	onUpdate func(profile *Profile)
+	failedAt map[string]time.Time
+}
+
+// NewUpdater 创建定时更新器
+func NewUpdater(store *Store, importer *Importer) *Updater {
+	return &Updater{
+		store:    store,
+		importer: importer,
+		failedAt: make(map[string]time.Time),
+	}
+}
+
+// OnUpdate 设置 profile 内容变化后的回调
+func (u *Updater) OnUpdate(fn func(profile *Profile)) {
+	u.onUpdate = fn
+}
+
+// Start 在后台启动定时更新，ctx 取消时退出
+func (u *Updater) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		u.RefreshDue(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				u.RefreshDue(ctx)
+			}
+		}
+	}()
+}
+
+// RefreshDue 刷新所有到期的 profile
+func (u *Updater) RefreshDue(ctx context.Context) {
+	profiles, err := u.store.List()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list profiles for auto update")
+		return
+	}
+
+	now := time.Now()
+	for _, p := range profiles {
+		if ctx.Err() != nil {
+			return
+		}
+		// 聚合 profile 每次都检查，由各来源自己的更新周期决定是否下载
+		if !p.IsDue(now) && !p.IsAggregate() {
+			continue
+		}
+		if now.Before(u.failedAt[p.Name].Add(retryDelay)) {
+			continue
+		}
+
+		changed, err := u.importer.Refresh(ctx, p.Name)
+		if err != nil {
+			u.failedAt[p.Name] = now
+			logger.Error().Err(err).Str("name", p.Name).Msg("Profile auto update failed")
+			continue
+		}
+		delete(u.failedAt, p.Name)
+		if !changed || u.onUpdate == nil {
+			continue
+		}
+
+		updated, err := u.store.Get(p.Name)
+		if err != nil {
+			logger.Error().Err(err).Str("name", p.Name).Msg("Failed to read updated profile")
+			continue
+		}
+		u.onUpdate(updated)
+	}
+}
@@ -0,0 +1,147 @@
+package subscription
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// UserInfoHeader 订阅流量信息响应头
+	UserInfoHeader = "subscription-userinfo"
+
+	// QuotaWarnRatio 流量使用超过该比例时告警
+	QuotaWarnRatio = 0.9
+
+	// ExpireWarnBefore 距离到期小于该时长时告警
+	ExpireWarnBefore = 7 * 24 * time.Hour
+)
+
+// UserInfo 订阅流量与到期信息
+type UserInfo struct {
+	Upload   int64     `yaml:"upload"`
+	Download int64     `yaml:"download"`
+	Total    int64     `yaml:"total"`
+	Expire   time.Time `yaml:"expire,omitempty"`
+}
+
+// ParseUserInfo 解析 subscription-userinfo 响应头，
+// 格式：upload=1234; download=5678; total=1073741824; expire=1700000000
+func ParseUserInfo(header string) (*UserInfo, error) {
+	info := &UserInfo{}
+	found := false
+
+	for _, field := range strings.Split(header, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		var target *int64
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "upload":
+			target = &info.Upload
+		case "download":
+			target = &info.Download
+		case "total":
+			target = &info.Total
+		case "expire":
+		default:
+			// 忽略未知字段，如 plan=pro
+			continue
+		}
+
+		// 部分服务商会返回浮点数
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q in %s", key, value, UserInfoHeader)
+		}
+		n := int64(f)
+
+		if target != nil {
+			*target = n
+		} else if n > 0 {
+			info.Expire = time.Unix(n, 0)
+		}
+		found = true
+	}
+
+	if !found {
+		return nil, fmt.Errorf("empty %s header", UserInfoHeader)
+	}
+	return info, nil
+}
+
+// Used 已用流量（上传 + 下载）
+func (u *UserInfo) Used() int64 {
+	return u.Upload + u.Download
+}
+
+// UsageRatio 流量使用比例，总量未知时返回 0
+func (u *UserInfo) UsageRatio() float64 {
+	if u.Total <= 0 {
+		return 0
+	}
+	return float64(u.Used()) / float64(u.Total)
+}
+
+// FormatTraffic 格式化流量使用情况，如 "12.3 GB / 100.0 GB (12%)"
+func (u *UserInfo) FormatTraffic() string {
+	if u.Total <= 0 {
+		return fmt.Sprintf("%s used", FormatBytes(u.Used()))
+	}
+	return fmt.Sprintf("%s / %s (%.0f%%)", FormatBytes(u.Used()), FormatBytes(u.Total), u.UsageRatio()*100)
+}
+
+// FormatExpire 格式化到期时间，如 "2026-11-01 (13 days left)"
+func (u *UserInfo) FormatExpire(now time.Time) string {
+	if u.Expire.IsZero() {
+		return "never"
+	}
+
+	date := u.Expire.Format("2006-01-02")
+	left := u.Expire.Sub(now)
+	if left <= 0 {
+		return fmt.Sprintf("%s (expired)", date)
+	}
+	return fmt.Sprintf("%s (%d days left)", date, int(left.Hours()/24))
+}
+
+// Warnings 返回流量或到期相关的告警信息
+func (u *UserInfo) Warnings(now time.Time) []string {
+	var warnings []string
+
+	if u.Total > 0 && u.UsageRatio() >= QuotaWarnRatio {
+		warnings = append(warnings, fmt.Sprintf("traffic quota almost exhausted: %s", u.FormatTraffic()))
+	}
+
+	if !u.Expire.IsZero() {
+		if left := u.Expire.Sub(now); left <= 0 {
+			warnings = append(warnings, fmt.Sprintf("subscription expired on %s", u.Expire.Format("2006-01-02")))
+		} else if left <= ExpireWarnBefore {
+			warnings = append(warnings, fmt.Sprintf("subscription expires soon: %s", u.FormatExpire(now)))
+		}
+	}
+
+	return warnings
+}
+
+// FormatBytes 将字节数格式化为可读字符串
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
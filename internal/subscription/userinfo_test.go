@@ -0,0 +1,62 @@
+package subscription
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseUserInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   UserInfo
+	}{
+		{
+			name:   "standard",
+			header: "upload=1024; download=2048; total=1073741824; expire=1700000000",
+			want:   UserInfo{Upload: 1024, Download: 2048, Total: 1073741824, Expire: time.Unix(1700000000, 0)},
+		},
+		{
+			name:   "float values and no expire",
+			header: "upload=1.5e3;download=2048.9;total=4096;expire=0",
+			want:   UserInfo{Upload: 1500, Download: 2048, Total: 4096},
+		},
+		{
+			// 未知字段不应影响其他字段的解析
+			name:   "unknown keys are skipped",
+			header: "plan=pro; upload=10; download=20; total=100; reset=monthly",
+			want:   UserInfo{Upload: 10, Download: 20, Total: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUserInfo(tt.header)
+			if err != nil {
+				t.Fatalf("ParseUserInfo: %v", err)
+			}
+			if got.Upload != tt.want.Upload || got.Download != tt.want.Download ||
+				got.Total != tt.want.Total || !got.Expire.Equal(tt.want.Expire) {
+				t.Errorf("ParseUserInfo() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUserInfoErrors(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"upload=abc; total=100", "invalid upload value"},
+		{"plan=pro; reset=monthly", "empty"},
+		{"", "empty"},
+	}
+	for _, tt := range tests {
+		_, err := ParseUserInfo(tt.header)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseUserInfo(%q) error = %v, want containing %q", tt.header, err, tt.want)
+		}
+	}
+}
@@ -42,6 +42,20 @@ func Init(logDir string, debug bool) error {
 	return nil
 }
 
+// SetLevel 按 mihomo 日志级别名称（debug/info/warning/error/silent）设置日志级别
+func SetLevel(level string) {
+	levels := map[string]zerolog.Level{
+		"debug":   zerolog.DebugLevel,
+		"info":    zerolog.InfoLevel,
+		"warning": zerolog.WarnLevel,
+		"error":   zerolog.ErrorLevel,
+		"silent":  zerolog.Disabled,
+	}
+	if l, ok := levels[level]; ok {
+		zerolog.SetGlobalLevel(l)
+	}
+}
+
 // Info 记录 Info 级别日志
 func Info() *zerolog.Event {
 	return log.Info()
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// PrivateFileMode 含凭据的文件（配置、profile、订阅缓存）的权限，仅所有者可读写
+const PrivateFileMode os.FileMode = 0600
+
+// WriteFileAtomic 原子写入文件：先写临时文件并 fsync，再重命名覆盖目标文件
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// 任何一步失败都清理临时文件
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to chmod temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	success = true
+
+	return nil
+}
+
+// LockFile 对 path 旁的 .lock 文件加排他咨询锁（flock），阻塞直到获得锁。
+// 用于多个 CLI 进程和后台服务之间串行化同一文件的读改写，返回的函数释放锁。
+func LockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, PrivateFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// BackupFile 轮转备份文件的当前内容：path.bak 为最新，path.bak.1 ... path.bak.<keep-1> 依次更旧。
+// 文件不存在时跳过；备份与原文件权限无关，统一使用 PrivateFileMode。
+func BackupFile(path string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read file for backup: %w", err)
+	}
+
+	backup := func(n int) string {
+		if n == 0 {
+			return path + ".bak"
+		}
+		return fmt.Sprintf("%s.bak.%d", path, n)
+	}
+
+	// 从最旧的开始依次后移，超出数量的被覆盖
+	for n := keep - 2; n >= 0; n-- {
+		if err := os.Rename(backup(n), backup(n+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate backup: %w", err)
+		}
+	}
+
+	if err := WriteFileAtomic(backup(0), data, PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
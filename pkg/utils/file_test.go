@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("new"), PrivateFileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("content = %q, want new", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != PrivateFileMode {
+		t.Errorf("mode = %v, want %v", info.Mode().Perm(), PrivateFileMode)
+	}
+	assertNoTempFiles(t, filepath.Dir(path), "config.yaml")
+}
+
+func TestWriteFileAtomicFailureKeepsTarget(t *testing.T) {
+	// 目标是目录时重命名失败，临时文件被清理
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := os.MkdirAll(filepath.Join(target, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(target, []byte("data"), PrivateFileMode); err == nil {
+		t.Fatal("expected error")
+	}
+	assertNoTempFiles(t, dir, "target")
+}
+
+func TestWriteFileAtomicConcurrentReaders(t *testing.T) {
+	// 并发写入时读者只会看到某次完整写入的内容
+	path := filepath.Join(t.TempDir(), "index.yaml")
+	payload := func(i int) []byte {
+		return bytes.Repeat([]byte{byte('a' + i)}, 64*1024)
+	}
+	if err := WriteFileAtomic(path, payload(0), PrivateFileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := WriteFileAtomic(path, payload(i), PrivateFileMode); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(data) != 64*1024 || bytes.Count(data, data[:1]) != len(data) {
+				t.Errorf("read a partially written file (%d bytes)", len(data))
+			}
+		}()
+	}
+	wg.Wait()
+	assertNoTempFiles(t, filepath.Dir(path), "index.yaml")
+}
+
+func TestLockFileSerializesReadModifyWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "counter")
+	const n = 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := LockFile(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+
+			count := 0
+			if data, err := os.ReadFile(path); err == nil {
+				count, _ = strconv.Atoi(string(data))
+			}
+			if err := WriteFileAtomic(path, []byte(strconv.Itoa(count+1)), PrivateFileMode); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != strconv.Itoa(n) {
+		t.Errorf("counter = %s, want %d: lock did not serialize writers", data, n)
+	}
+}
+
+func TestBackupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	// 文件不存在时跳过
+	if err := BackupFile(path, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("v%d", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := BackupFile(path, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{
+		path + ".bak":   "v4",
+		path + ".bak.1": "v3",
+		path + ".bak.2": "v2",
+	} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".bak.3"); !os.IsNotExist(err) {
+		t.Errorf("backup beyond keep limit exists: %v", err)
+	}
+}
+
+// assertNoTempFiles 检查目录中除 keep 外没有遗留的临时文件
+func assertNoTempFiles(t *testing.T, dir, keep string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != keep {
+			t.Errorf("unexpected file left behind: %s", e.Name())
+		}
+	}
+}